@@ -0,0 +1,148 @@
+package adc
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// User account control flags. See 'userAccountControl' and 'msDS-User-Account-Control-Computed' attributes.
+type UserAccountControl uint32
+
+const (
+	UACScript                       UserAccountControl = 0x0001
+	UACAccountDisable               UserAccountControl = 0x0002
+	UACHomeDirRequired              UserAccountControl = 0x0008
+	UACLockout                      UserAccountControl = 0x0010
+	UACPasswordNotRequired          UserAccountControl = 0x0020
+	UACPasswordCantChange           UserAccountControl = 0x0040
+	UACEncryptedTextPasswordAllowed UserAccountControl = 0x0080
+	UACTempDuplicateAccount         UserAccountControl = 0x0100
+	UACNormalAccount                UserAccountControl = 0x0200
+	UACInterdomainTrustAccount      UserAccountControl = 0x0800
+	UACWorkstationTrustAccount      UserAccountControl = 0x1000
+	UACServerTrustAccount           UserAccountControl = 0x2000
+	UACDontExpirePassword           UserAccountControl = 0x10000
+	UACMNSLogonAccount              UserAccountControl = 0x20000
+	UACSmartcardRequired            UserAccountControl = 0x40000
+	UACTrustedForDelegation         UserAccountControl = 0x80000
+	UACNotDelegated                 UserAccountControl = 0x100000
+	UACUseDESKeyOnly                UserAccountControl = 0x200000
+	UACDontRequirePreauth           UserAccountControl = 0x400000
+	UACPasswordExpired              UserAccountControl = 0x800000
+	UACTrustedToAuthForDelegation   UserAccountControl = 0x1000000
+	UACPartialSecretsAccount        UserAccountControl = 0x4000000
+)
+
+// Attributes used to compute user account control flags.
+// Lockout and password expiration flags are set by AD only in computed attribute.
+var accountControlAttributes = []string{"userAccountControl", "msDS-User-Account-Control-Computed"}
+
+// Returns true if all provided flags are set.
+func (f UserAccountControl) Has(flags UserAccountControl) bool {
+	return f&flags == flags
+}
+
+// Returns account control flags from entry attributes.
+func entryAccountControl(entry *ldap.Entry) UserAccountControl {
+	var result UserAccountControl
+	for _, a := range accountControlAttributes {
+		v, err := strconv.ParseUint(entry.GetAttributeValue(a), 10, 32)
+		if err == nil {
+			result |= UserAccountControl(v)
+		}
+	}
+	return result
+}
+
+// Returns true if user account is disabled.
+func (u *User) IsDisabled() bool {
+	return u.AccountControl.Has(UACAccountDisable)
+}
+
+// Returns true if user account is locked out.
+func (u *User) IsLockedOut() bool {
+	return u.AccountControl.Has(UACLockout)
+}
+
+// Returns true if user password never expires.
+func (u *User) PasswordNeverExpires() bool {
+	return u.AccountControl.Has(UACDontExpirePassword)
+}
+
+// Returns true if user password is expired.
+func (u *User) PasswordExpired() bool {
+	return u.AccountControl.Has(UACPasswordExpired)
+}
+
+// Returns true if smartcard is required to log on.
+func (u *User) SmartcardRequired() bool {
+	return u.AccountControl.Has(UACSmartcardRequired)
+}
+
+// Same as EnableUserContext with background context.
+func (cl *Client) EnableUser(userId string) error {
+	return cl.EnableUserContext(context.Background(), userId)
+}
+
+// Enables user account by ID.
+func (cl *Client) EnableUserContext(ctx context.Context, userId string) error {
+	return cl.updateUserAccountControl(ctx, userId, 0, UACAccountDisable)
+}
+
+// Same as DisableUserContext with background context.
+func (cl *Client) DisableUser(userId string) error {
+	return cl.DisableUserContext(context.Background(), userId)
+}
+
+// Disables user account by ID.
+func (cl *Client) DisableUserContext(ctx context.Context, userId string) error {
+	return cl.updateUserAccountControl(ctx, userId, UACAccountDisable, 0)
+}
+
+// Sets and clears provided flags in user 'userAccountControl' attribute keeping other flags as is.
+func (cl *Client) updateUserAccountControl(ctx context.Context, userId string, set, clear UserAccountControl) error {
+	user, err := cl.findUser(ctx, userId, "userAccountControl")
+	if err != nil {
+		return err
+	}
+
+	current := user.GetStringAttribute("userAccountControl")
+	value, err := strconv.ParseUint(current, 10, 32)
+	if err != nil {
+		return fmt.Errorf("can't parse user account control '%s': %w", current, err)
+	}
+
+	uac := (UserAccountControl(value) | set) &^ clear
+	if uac == UserAccountControl(value) {
+		cl.logger.Debugf("User '%s' account control is already up to date", userId)
+		return nil
+	}
+
+	cl.logger.Debugf("Updating user '%s' account control; Old: %d; New: %d", userId, value, uac)
+
+	// Deleting of the old value fails if it was changed concurrently, so other flags are never overwritten.
+	mr := ldap.NewModifyRequest(user.DN, nil)
+	mr.Delete("userAccountControl", []string{current})
+	mr.Add("userAccountControl", []string{strconv.FormatUint(uint64(uac), 10)})
+	return cl.modify(ctx, mr)
+}
+
+// Same as UnlockUserContext with background context.
+func (cl *Client) UnlockUser(userId string) error {
+	return cl.UnlockUserContext(context.Background(), userId)
+}
+
+// Unlocks locked out user account by ID.
+func (cl *Client) UnlockUserContext(ctx context.Context, userId string) error {
+	user, err := cl.findUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	cl.logger.Debugf("Unlocking user '%s'", userId)
+
+	return cl.updateAttribute(ctx, user.DN, "lockoutTime", []string{"0"})
+}
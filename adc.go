@@ -3,27 +3,40 @@ package adc
 
 import (
 	"context"
-	"crypto/tls"
 	"errors"
 	"fmt"
+	"net"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-ldap/ldap/v3"
 )
 
-// Active Direcotry client.
+// Active Direcotry client. Client is safe for concurrent use: each operation borrows
+// a bound connection from the client connections pool.
 type Client struct {
 	Config *Config
-	ldap   ldap.Client
 	logger Logger
+
+	// Guards connections pool and connected server URL.
+	mu   sync.RWMutex
+	pool *pool
+	// URL of the last connected server.
+	url string
+	// Round-robin URL strategy counter.
+	nextURL atomic.Uint32
+	// DNS resolver for domain controllers discovery.
+	resolver Resolver
 }
 
 // Creates new client and populate provided config and options.
 func New(cfg *Config, opts ...Option) *Client {
 	cl := &Client{
-		Config: populateConfig(cfg),
-		logger: newNopLogger(),
+		Config:   populateConfig(cfg),
+		logger:   newNopLogger(),
+		resolver: net.DefaultResolver,
 	}
 	for _, opt := range opts {
 		opt(cl)
@@ -38,51 +51,156 @@ func WithLogger(l Logger) Option {
 	return func(cl *Client) { cl.logger = l }
 }
 
-// Connects to AD server and store connection into client.
+// Same as ConnectContext with background context.
 func (cl *Client) Connect() error {
-	conn, err := cl.connect()
+	return cl.ConnectContext(context.Background())
+}
+
+// Connects to AD server and store connection into client connections pool.
+// Context deadline limits the dial time and cancellation interrupts the bind.
+// Previous connections pool is closed if client is already connected.
+func (cl *Client) ConnectContext(ctx context.Context) error {
+	conn, err := cl.connectBound(ctx)
 	if err != nil {
-		return fmt.Errorf("Failed to connect: %w", err)
+		return err
+	}
+
+	p := newPool(cl.Config.PoolSize, cl.Config.PoolIdleTimeout, cl.dialBound)
+	p.add(conn)
+
+	cl.mu.Lock()
+	old := cl.pool
+	cl.pool = p
+	cl.mu.Unlock()
+
+	if old != nil {
+		return old.close()
+	}
+	return nil
+}
+
+// Connects to AD server and binds with client bind account.
+func (cl *Client) connectBound(ctx context.Context) (ldap.Client, error) {
+	conn, url, err := cl.connect(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to connect: %w", err)
 	}
 
 	if cl.Config.Bind != nil {
-		if err := conn.Bind(cl.Config.Bind.DN, cl.Config.Bind.Password); err != nil {
-			return fmt.Errorf("Failed to bind: %w", err)
+		if err := withContext(ctx, func() error {
+			return conn.Bind(cl.Config.Bind.DN, cl.Config.Bind.Password)
+		}); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("Failed to bind: %w", wrapLDAPError("bind", cl.Config.Bind.DN, err))
 		}
 	}
 
-	cl.ldap = conn
+	cl.mu.Lock()
+	cl.url = url
+	cl.mu.Unlock()
 
-	return nil
+	return conn, nil
+}
+
+// Connects to the first available server. Servers are tried in order of configured URL strategy.
+// Returns connection and URL of the connected server.
+func (cl *Client) connect(ctx context.Context) (ldap.Client, string, error) {
+	urls, err := cl.serverURLs(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var errs []error
+	for _, u := range urls {
+		if err := ctx.Err(); err != nil {
+			return nil, "", err
+		}
+		conn, err := cl.dial(ctx, u)
+		if err == nil {
+			cl.logger.Debugf("Connected to '%s'", u)
+			return conn, u, nil
+		}
+		cl.logger.Debugf("Failed to connect to '%s': %s", u, err.Error())
+		errs = append(errs, fmt.Errorf("%s: %w", u, err))
+	}
+	return nil, "", errors.Join(errs...)
+}
+
+// Connects to the server by URL. Connection is upgraded with StartTLS if enabled.
+func (cl *Client) dial(ctx context.Context, serverURL string) (ldap.Client, error) {
+	dialer := &net.Dialer{Timeout: cl.Config.Timeout}
+	if deadline, ok := ctx.Deadline(); ok {
+		dialer.Deadline = deadline
+	}
+
+	isLdaps := strings.HasPrefix(serverURL, "ldaps://")
+	if isLdaps && cl.Config.StartTLS {
+		return nil, errors.New("StartTLS can't be used with 'ldaps://' URL")
+	}
+
+	tlsConfig, err := cl.tlsConfig(serverURL)
+	if err != nil {
+		return nil, err
+	}
+
+	dialOpts := []ldap.DialOpt{ldap.DialWithDialer(dialer)}
+	if isLdaps {
+		dialOpts = append(dialOpts, ldap.DialWithTLSConfig(tlsConfig))
+	}
+	conn, err := ldap.DialURL(serverURL, dialOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	if cl.Config.StartTLS {
+		if err := withContext(ctx, func() error { return conn.StartTLS(tlsConfig) }); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("StartTLS failed: %w", err)
+		}
+	}
+	return conn, nil
 }
 
-func (cl *Client) connect() (ldap.Client, error) {
-	var dialOpts []ldap.DialOpt
-	if strings.HasPrefix(cl.Config.URL, "ldaps://") {
-		dialOpts = append(
-			dialOpts, ldap.DialWithTLSConfig(&tls.Config{InsecureSkipVerify: cl.Config.InsecureTLS}),
-		)
+// Runs provided LDAP operation and stops waiting for its result when context is done.
+// Note that server may still complete the operation after context cancellation.
+func withContext(ctx context.Context, op func() error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- op() }()
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
 	}
-	return ldap.DialURL(cl.Config.URL, dialOpts...)
 }
 
-// Closes connection to AD.
+// Closes connections to AD. Connections borrowed by running operations are closed when operations finish.
 func (cl *Client) Disconnect() error {
-	if cl.ldap == nil {
+	cl.mu.Lock()
+	p := cl.pool
+	cl.pool = nil
+	cl.url = ""
+	cl.mu.Unlock()
+
+	if p == nil {
 		return nil
 	}
-	return cl.ldap.Close()
+	return p.close()
 }
 
 // Checks connections to AD and tries to reconnect if the connection is lost.
+// Set Config.Reconnect to restore lost connections automatically on operations.
 func (cl *Client) Reconnect(ctx context.Context, tickerDuration time.Duration, maxAttempts int) error {
-	_, connErr := cl.searchEntry(&ldap.SearchRequest{
-		BaseDN:       cl.Config.SearchBase,
-		Scope:        ldap.ScopeWholeSubtree,
+	// RootDSE search works for any bind account, including anonymous.
+	_, connErr := cl.search(ctx, &ldap.SearchRequest{
+		Scope:        ldap.ScopeBaseObject,
 		DerefAliases: ldap.NeverDerefAliases,
 		TimeLimit:    int(cl.Config.Timeout.Seconds()),
-		Filter:       fmt.Sprintf(cl.Config.Users.FilterByDn, ldap.EscapeFilter(cl.Config.Bind.DN)),
-		Attributes:   []string{cl.Config.Users.IdAttribute},
+		Filter:       "(objectClass=*)",
+		Attributes:   []string{"1.1"},
 	})
 	if connErr == nil {
 		return nil
@@ -103,7 +221,7 @@ func (cl *Client) Reconnect(ctx context.Context, tickerDuration time.Duration, m
 		select {
 		case <-ticker.C:
 			if attempt >= maxAttempts {
-				return fmt.Errorf("failed after '%d' attempts. error: %s", attempt, connErr)
+				return fmt.Errorf("failed after '%d' attempts. error: %w", attempt, connErr)
 			}
 			attempt++
 			cl.logger.Debugf("Reconnecting to AD server. Attempt: %d", attempt)
@@ -112,7 +230,7 @@ func (cl *Client) Reconnect(ctx context.Context, tickerDuration time.Duration, m
 				return fmt.Errorf("failed to disconnect from the server: %w", err)
 			}
 
-			if err := cl.Connect(); err == nil {
+			if err := cl.ConnectContext(ctx); err == nil {
 				cl.logger.Debug("Successfully reconneted to AD server")
 				return nil
 			}
@@ -124,14 +242,14 @@ func (cl *Client) Reconnect(ctx context.Context, tickerDuration time.Duration, m
 
 // SearchEntry Perfrom search for single ldap entry.
 // Returns nil if no entries found.
-// Returns 'ErrTooManyEntriesFound' error if entries more that one.
-func (cl *Client) searchEntry(req *ldap.SearchRequest) (*ldap.Entry, error) {
-	result, err := cl.ldap.Search(req)
+// Returns 'ErrTooManyEntries' error if entries more that one.
+func (cl *Client) searchEntry(ctx context.Context, req *ldap.SearchRequest) (*ldap.Entry, error) {
+	result, err := cl.search(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	if len(result.Entries) > 1 {
-		return nil, errors.New("too many entries found")
+		return nil, ErrTooManyEntries
 	}
 	if len(result.Entries) < 1 {
 		return nil, nil
@@ -139,48 +257,124 @@ func (cl *Client) searchEntry(req *ldap.SearchRequest) (*ldap.Entry, error) {
 	return result.Entries[0], nil
 }
 
-// SearchEntries Perfroms search for ldap entries.
-func (cl *Client) searchEntries(req *ldap.SearchRequest) ([]*ldap.Entry, error) {
-	result, err := cl.ldap.Search(req)
-	if err != nil {
+// SearchEntries Perfroms paged search for ldap entries.
+func (cl *Client) searchEntries(ctx context.Context, req *ldap.SearchRequest) ([]*ldap.Entry, error) {
+	p := cl.newPager(req)
+	var result []*ldap.Entry
+	for !p.done {
+		entries, err := p.next(ctx)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, entries...)
+	}
+	return result, nil
+}
+
+// Performs search request. Search is abandoned when context is done.
+func (cl *Client) search(ctx context.Context, req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	var result *ldap.SearchResult
+	err := cl.withConnRetry(ctx, func(conn ldap.Client) error {
+		var err error
+		result, err = searchConn(ctx, conn, req)
+		return err
+	})
+	return result, err
+}
+
+// Performs search request on provided connection. Search is abandoned when context is done.
+func searchConn(ctx context.Context, conn ldap.Client, req *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	// Search on closed connection returns no entries without error.
+	if conn.IsClosing() {
+		return nil, wrapLDAPError("search", req.BaseDN, ldap.NewError(ldap.ErrorNetwork, errors.New("ldap: connection closed")))
+	}
+
+	searchCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	result := &ldap.SearchResult{}
+	resp := conn.SearchAsync(searchCtx, req, 0)
+	for resp.Next() {
+		switch {
+		case resp.Entry() != nil:
+			result.Entries = append(result.Entries, resp.Entry())
+		case resp.Referral() != "":
+			result.Referrals = append(result.Referrals, resp.Referral())
+		default:
+			result.Controls = append(result.Controls, resp.Controls()...)
+		}
+	}
+	if err := ctx.Err(); err != nil {
 		return nil, err
 	}
-	return result.Entries, nil
+	if err := resp.Err(); err != nil {
+		return nil, wrapLDAPError("search", req.BaseDN, err)
+	}
+	return result, nil
 }
 
 // Performs update for provided entry attribure by entry DN.
-func (cl *Client) updateAttribute(dn string, attribute string, values []string) error {
+func (cl *Client) updateAttribute(ctx context.Context, dn string, attribute string, values []string) error {
 	mr := ldap.NewModifyRequest(dn, nil)
 	mr.Replace(attribute, values)
-	return cl.ldap.Modify(mr)
+	return cl.modify(ctx, mr)
+}
+
+func (cl *Client) modify(ctx context.Context, req *ldap.ModifyRequest) error {
+	err := cl.withConn(ctx, func(conn ldap.Client) error {
+		return withContext(ctx, func() error { return conn.Modify(req) })
+	})
+	return wrapLDAPError("modify", req.DN, err)
+}
+
+// Same as CheckAuthByDNContext with background context.
+func (cl *Client) CheckAuthByDN(dn, password string) error {
+	return cl.CheckAuthByDNContext(context.Background(), dn, password)
 }
 
 // Tries to authorise in AcitveDirecotry by provided DN and password and return error if failed.
 // Use this method to check if user can be authenticated in AD.
-func (cl *Client) CheckAuthByDN(dn, password string) error {
-	conn, err := cl.connect()
+// Returns *AuthError with failure reason if credentials are rejected.
+func (cl *Client) CheckAuthByDNContext(ctx context.Context, dn, password string) error {
+	conn, _, err := cl.connect(ctx)
 	if err != nil {
 		return err
 	}
 	defer conn.Close()
 
-	if err := conn.Bind(dn, password); err != nil {
-		return err
-	}
-	return nil
+	err = withContext(ctx, func() error { return conn.Bind(dn, password) })
+	return authError(dn, wrapLDAPError("bind", dn, err))
 }
 
-func (cl *Client) createEntry(dn string, attributes []ldap.Attribute) error {
-	cl.logger.Debugf("Creating '%s'; Attributes: %#v", dn, attributes)
+func (cl *Client) createEntry(ctx context.Context, dn string, attributes []ldap.Attribute) error {
+	cl.logger.Debugf("Creating '%s'; Attributes: %#v", dn, maskPasswords(attributes))
 
 	req := &ldap.AddRequest{
 		DN:         dn,
 		Attributes: attributes,
 	}
-	return cl.ldap.Add(req)
+	err := cl.withConn(ctx, func(conn ldap.Client) error {
+		return withContext(ctx, func() error { return conn.Add(req) })
+	})
+	return wrapLDAPError("add", dn, err)
 }
 
-func (cl *Client) deleteEntry(dn string) error {
+// Returns copy of attributes with password values masked. Used for logging.
+func maskPasswords(attributes []ldap.Attribute) []ldap.Attribute {
+	result := make([]ldap.Attribute, len(attributes))
+	for i, a := range attributes {
+		result[i] = a
+		if strings.EqualFold(a.Type, "unicodePwd") || strings.EqualFold(a.Type, "userPassword") {
+			result[i].Vals = []string{"***"}
+		}
+	}
+	return result
+}
+
+func (cl *Client) deleteEntry(ctx context.Context, dn string) error {
 	cl.logger.Debugf("Deleting: '%s'", dn)
-	return cl.ldap.Del(&ldap.DelRequest{DN: dn})
+	err := cl.withConn(ctx, func(conn ldap.Client) error {
+		return withContext(ctx, func() error { return conn.Del(&ldap.DelRequest{DN: dn}) })
+	})
+	return wrapLDAPError("delete", dn, err)
 }
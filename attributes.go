@@ -0,0 +1,204 @@
+package adc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Converts ldap entry attributes to the attributes map.
+// Single-valued attributes are stored as string and multi-valued attributes as []string.
+// Attributes listed in client config binary attributes are stored as []byte or [][]byte.
+// Known binary attributes such as objectGUID and objectSid are decoded to their string form.
+func (cl *Client) entryAttributes(entry *ldap.Entry) map[string]interface{} {
+	result := make(map[string]interface{}, len(entry.Attributes))
+	for _, a := range entry.Attributes {
+		if cl.isBinaryAttribute(a.Name) {
+			switch len(a.ByteValues) {
+			case 0:
+				result[a.Name] = []byte{}
+			case 1:
+				result[a.Name] = a.ByteValues[0]
+			default:
+				result[a.Name] = a.ByteValues
+			}
+			continue
+		}
+
+		values := make([]string, len(a.ByteValues))
+		for i, v := range a.ByteValues {
+			values[i] = decodeAttributeValue(a.Name, v)
+		}
+		switch len(values) {
+		case 0:
+			result[a.Name] = ""
+		case 1:
+			result[a.Name] = values[0]
+		default:
+			result[a.Name] = values
+		}
+	}
+	return result
+}
+
+// Returns first value of the entry attribute. Known binary attributes are decoded to their string form.
+func entryAttributeValue(entry *ldap.Entry, name string) string {
+	values := entry.GetRawAttributeValues(name)
+	if len(values) == 0 {
+		return ""
+	}
+	return decodeAttributeValue(name, values[0])
+}
+
+func (cl *Client) isBinaryAttribute(name string) bool {
+	return containsFold(cl.Config.BinaryAttributes, name)
+}
+
+// Reports whether attributes list contains provided attribute name. Attribute names are case-insensitive.
+func containsFold(attributes []string, name string) bool {
+	for _, a := range attributes {
+		if strings.EqualFold(a, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// Decodes known binary attributes values to their string form. Other values are returned as is.
+func decodeAttributeValue(name string, value []byte) string {
+	switch {
+	case strings.EqualFold(name, "objectGUID"):
+		if guid, err := DecodeGUID(value); err == nil {
+			return guid
+		}
+	case strings.EqualFold(name, "objectSid"):
+		if sid, err := DecodeSID(value); err == nil {
+			return sid
+		}
+	}
+	return string(value)
+}
+
+// Decodes binary objectGUID value to the standard GUID string format.
+// Example: '01234567-89ab-cdef-0123-456789abcdef'.
+func DecodeGUID(b []byte) (string, error) {
+	if len(b) != 16 {
+		return "", fmt.Errorf("invalid GUID length: %d", len(b))
+	}
+	return fmt.Sprintf("%08x-%04x-%04x-%x-%x",
+		binary.LittleEndian.Uint32(b[0:4]),
+		binary.LittleEndian.Uint16(b[4:6]),
+		binary.LittleEndian.Uint16(b[6:8]),
+		b[8:10],
+		b[10:16],
+	), nil
+}
+
+// Decodes binary objectSid value to the string format. Example: 'S-1-5-21-1004336348-1177238915-682003330-512'.
+func DecodeSID(b []byte) (string, error) {
+	if len(b) < 8 {
+		return "", fmt.Errorf("invalid SID length: %d", len(b))
+	}
+	count := int(b[1])
+	if len(b) != 8+4*count {
+		return "", fmt.Errorf("invalid SID length %d for %d sub-authorities", len(b), count)
+	}
+
+	var authority uint64
+	for _, v := range b[2:8] {
+		authority = authority<<8 | uint64(v)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "S-%d-%d", b[0], authority)
+	for i := 0; i < count; i++ {
+		fmt.Fprintf(&sb, "-%d", binary.LittleEndian.Uint32(b[8+4*i:]))
+	}
+	return sb.String(), nil
+}
+
+// Returns string attribute value from attributes map. Returns first value for multi-valued attributes.
+func getStringAttribute(attrs map[string]interface{}, name string) string {
+	switch v := attrs[name].(type) {
+	case string:
+		return v
+	case []string:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// Returns all string attribute values from attributes map.
+func getStringsAttribute(attrs map[string]interface{}, name string) []string {
+	switch v := attrs[name].(type) {
+	case string:
+		return []string{v}
+	case []string:
+		return v
+	}
+	return nil
+}
+
+// Returns bytes attribute value from attributes map. Returns first value for multi-valued attributes.
+func getBytesAttribute(attrs map[string]interface{}, name string) []byte {
+	switch v := attrs[name].(type) {
+	case []byte:
+		return v
+	case [][]byte:
+		if len(v) > 0 {
+			return v[0]
+		}
+	}
+	return nil
+}
+
+// Returns integer attribute value from attributes map. Returns false if attribute not exists or isn't integer.
+func getIntAttribute(attrs map[string]interface{}, name string) (int64, bool) {
+	v, err := strconv.ParseInt(getStringAttribute(attrs, name), 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// Returns time attribute value from attributes map. Supports both Windows FILETIME integers
+// (pwdLastSet, accountExpires, etc.) and GeneralizedTime strings (whenCreated, whenChanged, etc.).
+// Returns false if attribute not exists or can't be parsed as time.
+func getTimeAttribute(attrs map[string]interface{}, name string) (time.Time, bool) {
+	value := getStringAttribute(attrs, name)
+	if value == "" {
+		return time.Time{}, false
+	}
+
+	if ft, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return FileTimeToTime(ft), true
+	}
+
+	t, err := time.Parse(generalizedTimeLayout, value)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t.UTC(), true
+}
+
+// GeneralizedTime layout used by AD. Fractional seconds are accepted by time.Parse.
+const generalizedTimeLayout = "20060102150405Z0700"
+
+// Difference between Windows FILETIME epoch (1601-01-01) and Unix epoch in seconds.
+const fileTimeEpochDiff = 11644473600
+
+// Converts Windows FILETIME value (100-nanosecond intervals since 1601-01-01 UTC) to time.
+// Returns zero time for AD 'never' values: 0 and 0x7FFFFFFFFFFFFFFF.
+func FileTimeToTime(ft int64) time.Time {
+	if ft <= 0 || ft == math.MaxInt64 {
+		return time.Time{}
+	}
+	return time.Unix(ft/1e7-fileTimeEpochDiff, (ft%1e7)*100).UTC()
+}
@@ -0,0 +1,235 @@
+package adc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Reason of failed authentication.
+type AuthReason string
+
+const (
+	// Wrong password or unknown user.
+	AuthReasonInvalidCredentials AuthReason = "invalid_credentials"
+	// User not found. Most callers should show it as invalid credentials to not reveal existing users.
+	AuthReasonUserNotFound AuthReason = "user_not_found"
+	// Account is locked out after too many failed attempts.
+	AuthReasonAccountLocked AuthReason = "account_locked"
+	// Account is disabled.
+	AuthReasonAccountDisabled AuthReason = "account_disabled"
+	// Account is expired by 'accountExpires' attribute.
+	AuthReasonAccountExpired AuthReason = "account_expired"
+	// Password is expired.
+	AuthReasonPasswordExpired AuthReason = "password_expired"
+	// User must change password at next logon.
+	AuthReasonMustChangePassword AuthReason = "must_change_password"
+	// Logon isn't permitted at this time.
+	AuthReasonLogonHoursRestricted AuthReason = "logon_hours_restricted"
+	// Logon isn't permitted from this workstation.
+	AuthReasonWorkstationRestricted AuthReason = "workstation_restricted"
+	// Credentials are valid, but user isn't allowed by authentication policy.
+	AuthReasonNotAuthorized AuthReason = "not_authorized"
+)
+
+// AD bind failure sub-codes from 'data XXX' part of the diagnostic message.
+var authReasons = map[string]AuthReason{
+	"525": AuthReasonUserNotFound,
+	"52e": AuthReasonInvalidCredentials,
+	"530": AuthReasonLogonHoursRestricted,
+	"531": AuthReasonWorkstationRestricted,
+	"532": AuthReasonPasswordExpired,
+	"533": AuthReasonAccountDisabled,
+	"701": AuthReasonAccountExpired,
+	"773": AuthReasonMustChangePassword,
+	"775": AuthReasonAccountLocked,
+}
+
+var authDataRegexp = regexp.MustCompile(`(?i)\bdata ([0-9a-f]+)\b`)
+
+// Authentication failure error. Use errors.As to get failure reason.
+type AuthError struct {
+	Reason AuthReason
+	// DN used for bind.
+	DN string
+	// Authentication policy decision. Set only for 'AuthReasonNotAuthorized' reason.
+	Decision *AuthDecision
+	Err      error
+}
+
+func (e *AuthError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("authentication failed (%s)", e.Reason)
+	}
+	return fmt.Sprintf("authentication failed (%s): %s", e.Reason, e.Err.Error())
+}
+
+func (e *AuthError) Unwrap() error {
+	return e.Err
+}
+
+// Converts bind invalid credentials error to AuthError. Other errors are returned as is.
+// Servers that don't provide AD sub-code are reported with invalid credentials reason.
+func authError(dn string, err error) error {
+	var lerr *ldap.Error
+	if !errors.As(err, &lerr) || lerr.ResultCode != ldap.LDAPResultInvalidCredentials {
+		return err
+	}
+
+	reason := AuthReasonInvalidCredentials
+	if m := authDataRegexp.FindStringSubmatch(lerr.Err.Error()); m != nil {
+		if r, ok := authReasons[strings.ToLower(m[1])]; ok {
+			reason = r
+		}
+	}
+	return &AuthError{Reason: reason, DN: dn, Err: err}
+}
+
+// Authenticates user by login name and password. Login can be 'sAMAccountName', 'userPrincipalName' or 'mail'.
+// User is searched using client bind account and password is checked by bind on a separate connection.
+// Returns authenticated user with groups or *AuthError if credentials are rejected.
+func (cl *Client) Authenticate(ctx context.Context, login, password string) (*User, error) {
+	return cl.authenticate(ctx, login, password, false)
+}
+
+func (cl *Client) authenticate(ctx context.Context, login, password string, nestedGroups bool) (*User, error) {
+	// AD treats bind with empty password as anonymous bind, which succeeds.
+	if login == "" || password == "" {
+		return nil, &AuthError{Reason: AuthReasonInvalidCredentials, Err: errors.New("login and password are required")}
+	}
+
+	user, err := cl.GetUserContext(ctx, GetUserArgs{
+		Filter:           fmt.Sprintf(cl.Config.Users.FilterByLogin, ldap.EscapeFilter(login)),
+		SkipGroupsSearch: true,
+	})
+	if errors.Is(err, ErrNotFound) {
+		return nil, &AuthError{Reason: AuthReasonUserNotFound, Err: err}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("can't get user '%s': %w", login, err)
+	}
+
+	if err := cl.CheckAuthByDNContext(ctx, user.DN, password); err != nil {
+		return nil, err
+	}
+	cl.logger.Debugf("User '%s' authenticated as '%s'", login, user.DN)
+
+	groups, err := cl.getUserGroups(ctx, user.DN, nestedGroups)
+	if err != nil {
+		return nil, fmt.Errorf("can't get user groups: %w", err)
+	}
+	user.Groups = groups
+
+	return user, nil
+}
+
+// Group membership rules for authentication. Groups are matched by ID or DN.
+type AuthPolicy struct {
+	// User must be a member of any of these groups. All groups are required if RequireAllGroups is set.
+	// Any authenticated user is allowed if no required groups provided.
+	RequiredGroups []string `json:"required_groups"`
+	// Requires membership in all required groups instead of any of them.
+	RequireAllGroups bool `json:"require_all_groups"`
+	// Members of any of these groups are denied. Denied groups take precedence over required groups.
+	DeniedGroups []string `json:"denied_groups"`
+	// Evaluates membership in nested groups too. Only direct membership is checked otherwise.
+	NestedGroups bool `json:"nested_groups"`
+}
+
+// Authentication policy rule that allowed or blocked the user.
+type AuthRule string
+
+const (
+	// Allowed: policy has no required groups.
+	AuthRuleNoRequiredGroups AuthRule = "no_required_groups"
+	// Allowed: user is a member of required group.
+	AuthRuleRequiredGroup AuthRule = "required_group"
+	// Allowed: user is a member of all required groups.
+	AuthRuleAllRequiredGroups AuthRule = "all_required_groups"
+	// Blocked: user is a member of denied group.
+	AuthRuleDeniedGroup AuthRule = "denied_group"
+	// Blocked: user isn't a member of required group.
+	AuthRuleMissingRequiredGroup AuthRule = "missing_required_group"
+)
+
+// Authentication policy evaluation result.
+type AuthDecision struct {
+	Allowed bool     `json:"allowed"`
+	Rule    AuthRule `json:"rule"`
+	// Group that matched the rule: denied, required or missing required group.
+	// Empty if user isn't a member of any of required groups.
+	Group string `json:"group,omitempty"`
+}
+
+// Evaluates policy for provided user groups. Nested groups are taken into account only if
+// policy NestedGroups is set and user groups were fetched with nested groups.
+func (p AuthPolicy) Evaluate(user *User) AuthDecision {
+	isMember := func(group string) bool {
+		for _, g := range user.Groups {
+			if g.Inherited && !p.NestedGroups {
+				continue
+			}
+			if strings.EqualFold(g.Id, group) || strings.EqualFold(g.DN, group) {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, g := range p.DeniedGroups {
+		if isMember(g) {
+			return AuthDecision{Allowed: false, Rule: AuthRuleDeniedGroup, Group: g}
+		}
+	}
+
+	if len(p.RequiredGroups) == 0 {
+		return AuthDecision{Allowed: true, Rule: AuthRuleNoRequiredGroups}
+	}
+
+	if p.RequireAllGroups {
+		for _, g := range p.RequiredGroups {
+			if !isMember(g) {
+				return AuthDecision{Allowed: false, Rule: AuthRuleMissingRequiredGroup, Group: g}
+			}
+		}
+		return AuthDecision{Allowed: true, Rule: AuthRuleAllRequiredGroups}
+	}
+
+	for _, g := range p.RequiredGroups {
+		if isMember(g) {
+			return AuthDecision{Allowed: true, Rule: AuthRuleRequiredGroup, Group: g}
+		}
+	}
+	return AuthDecision{Allowed: false, Rule: AuthRuleMissingRequiredGroup}
+}
+
+// Authentication with policy result.
+type AuthResult struct {
+	User     *User        `json:"user"`
+	Decision AuthDecision `json:"decision"`
+}
+
+// Authenticates user by login name and password and checks user groups by provided policy.
+// Returns *AuthError with 'AuthReasonNotAuthorized' reason and policy decision if user isn't allowed by the policy.
+func (cl *Client) AuthenticateWithPolicy(ctx context.Context, login, password string, policy AuthPolicy) (*AuthResult, error) {
+	user, err := cl.authenticate(ctx, login, password, policy.NestedGroups)
+	if err != nil {
+		return nil, err
+	}
+
+	decision := policy.Evaluate(user)
+	if !decision.Allowed {
+		cl.logger.Debugf("User '%s' isn't authorized by '%s' rule", login, decision.Rule)
+		return nil, &AuthError{
+			Reason:   AuthReasonNotAuthorized,
+			DN:       user.DN,
+			Decision: &decision,
+			Err:      fmt.Errorf("blocked by '%s' rule", decision.Rule),
+		}
+	}
+	return &AuthResult{User: user, Decision: decision}, nil
+}
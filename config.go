@@ -7,12 +7,33 @@ import (
 type Config struct {
 	// LDAP server URL. Examle 'ldaps://cl.local:636'
 	URL string `json:"url"`
+	// Additional LDAP servers URLs for failover. Tried after URL if it's provided.
+	URLs []string `json:"urls"`
+	// Order in which servers URLs are tried on connect. Defaults to 'in_order'.
+	URLStrategy URLStrategy `json:"url_strategy"`
+	// Discover domain controllers through DNS SRV records instead of configured URLs.
+	Discovery *DiscoveryConfig `json:"discovery"`
 	// Use insecure SSL connection.
 	InsecureTLS bool `json:"insecure_tls"`
+	// Upgrade 'ldap://' connections to TLS with StartTLS before bind. Connection fails if upgrade fails.
+	StartTLS bool `json:"start_tls"`
+	// TLS settings for LDAPS and StartTLS connections.
+	TLS *TLSConfig `json:"tls"`
 	// Time limit for requests.
 	Timeout time.Duration
 	// Base OU for search requests.
 	SearchBase string `json:"search_base"`
+	// Page size for paged search requests. Should not exceed AD MaxPageSize policy.
+	PageSize uint32 `json:"page_size"`
+	// Max number of open connections, at least 2. Operations wait for a free connection if all connections are busy.
+	// Iterators hold a connection until iteration ends and can use all connections but one.
+	PoolSize int `json:"pool_size"`
+	// Idle connections are closed after this timeout. Defaults to 5 minutes, negative value disables the timeout.
+	PoolIdleTimeout time.Duration `json:"pool_idle_timeout"`
+	// Restore lost connections automatically on operations. Disabled if not provided.
+	Reconnect *ReconnectConfig `json:"reconnect"`
+	// Attributes to store as raw bytes in users and groups attributes.
+	BinaryAttributes []string `json:"binary_attributes"`
 
 	// Bind account info.
 	Bind *BindAccount `json:"bind"`
@@ -40,8 +61,15 @@ type UsersConfigs struct {
 	FilterById string `json:"filter_by_id"`
 	// LDAP filter to get user by DN.
 	FilterByDn string `json:"filter_by_dn"`
+	// LDAP filter to get user by login name on authentication.
+	FilterByLogin string `json:"filter_by_login"`
 	// LDAP filter to get user groups membership.
 	FilterGroupsByDn string `json:"filter_groups_by_dn"`
+	// LDAP filter to get user groups membership including nested groups.
+	// Default filter uses LDAP_MATCHING_RULE_IN_CHAIN matching rule.
+	FilterNestedGroupsByDn string `json:"filter_nested_groups_by_dn"`
+	// LDAP filter to list users.
+	FilterAll string `json:"filter_all"`
 }
 
 type GroupsConfigs struct {
@@ -56,7 +84,17 @@ type GroupsConfigs struct {
 	// LDAP filter to get group by DN.
 	FilterByDn string `json:"filter_by_dn"`
 	// LDAP filter to get group members.
+	//
+	// Deprecated: Group members are read from group 'member' attribute. Not used anymore.
 	FilterMembersByDn string `json:"filter_members_by_dn"`
+	// LDAP filter to get group member of any object type by ID.
+	// If nothing is found, computer account name ('ID$') is searched with the same filter.
+	FilterMemberById string `json:"filter_member_by_id"`
+	// LDAP filter to get group members including members of nested groups.
+	// Default filter uses LDAP_MATCHING_RULE_IN_CHAIN matching rule.
+	FilterNestedMembersByDn string `json:"filter_nested_members_by_dn"`
+	// LDAP filter to list groups.
+	FilterAll string `json:"filter_all"`
 }
 
 // Appends attributes to params in client config file.
@@ -78,20 +116,29 @@ func (cfg *Config) AppendGroupsAttributes(attrs ...string) {
 
 func getDefaultConfig() *Config {
 	return &Config{
-		Timeout: 10 * time.Second,
+		Timeout:         10 * time.Second,
+		PageSize:        1000,
+		PoolSize:        10,
+		PoolIdleTimeout: 5 * time.Minute,
 		Users: &UsersConfigs{
-			IdAttribute:      "sAMAccountName",
-			Attributes:       []string{"sAMAccountName", "givenName", "sn", "mail"},
-			FilterById:       "(&(objectClass=person)(sAMAccountName=%v))",
-			FilterByDn:       "(&(objectClass=person)(distinguishedName=%v))",
-			FilterGroupsByDn: "(&(objectClass=group)(member=%v))",
+			IdAttribute:            "sAMAccountName",
+			Attributes:             []string{"sAMAccountName", "givenName", "sn", "mail"},
+			FilterById:             "(&(objectClass=person)(sAMAccountName=%v))",
+			FilterByDn:             "(&(objectClass=person)(distinguishedName=%v))",
+			FilterByLogin:          "(&(objectClass=person)(|(sAMAccountName=%[1]v)(userPrincipalName=%[1]v)(mail=%[1]v)))",
+			FilterGroupsByDn:       "(&(objectClass=group)(member=%v))",
+			FilterNestedGroupsByDn: "(&(objectClass=group)(member:1.2.840.113556.1.4.1941:=%v))",
+			FilterAll:              "(objectClass=person)",
 		},
 		Groups: &GroupsConfigs{
-			IdAttribute:       "sAMAccountName",
-			Attributes:        []string{"sAMAccountName", "cn", "description"},
-			FilterById:        "(&(objectClass=group)(sAMAccountName=%v))",
-			FilterByDn:        "(&(objectClass=group)(distinguishedName=%v))",
-			FilterMembersByDn: "(&(objectCategory=person)(memberOf=%v))",
+			IdAttribute:             "sAMAccountName",
+			Attributes:              []string{"sAMAccountName", "cn", "description"},
+			FilterById:              "(&(objectClass=group)(sAMAccountName=%v))",
+			FilterByDn:              "(&(objectClass=group)(distinguishedName=%v))",
+			FilterMembersByDn:       "(&(objectCategory=person)(memberOf=%v))",
+			FilterMemberById:        "(sAMAccountName=%v)",
+			FilterNestedMembersByDn: "(memberOf:1.2.840.113556.1.4.1941:=%v)",
+			FilterAll:               "(objectClass=group)",
 		},
 	}
 }
@@ -104,15 +151,31 @@ func populateConfig(cfg *Config) *Config {
 	}
 
 	result.URL = cfg.URL
+	result.URLs = cfg.URLs
+	result.URLStrategy = cfg.URLStrategy
+	result.Discovery = cfg.Discovery
 	result.InsecureTLS = cfg.InsecureTLS
+	result.StartTLS = cfg.StartTLS
+	result.TLS = cfg.TLS
 	result.SearchBase = cfg.SearchBase
 	result.Users.SearchBase = cfg.SearchBase
 	result.Groups.SearchBase = cfg.SearchBase
 	result.Bind = cfg.Bind
+	result.BinaryAttributes = cfg.BinaryAttributes
+	result.Reconnect = cfg.Reconnect
 
 	if cfg.Timeout != 0 {
 		result.Timeout = cfg.Timeout
 	}
+	if cfg.PageSize != 0 {
+		result.PageSize = cfg.PageSize
+	}
+	if cfg.PoolSize != 0 {
+		result.PoolSize = cfg.PoolSize
+	}
+	if cfg.PoolIdleTimeout != 0 {
+		result.PoolIdleTimeout = cfg.PoolIdleTimeout
+	}
 
 	if cfg.Users != nil {
 		result.Users.SearchBase = cfg.Users.SearchBase
@@ -128,9 +191,18 @@ func populateConfig(cfg *Config) *Config {
 		if cfg.Users.FilterByDn != "" {
 			result.Users.FilterByDn = cfg.Users.FilterByDn
 		}
+		if cfg.Users.FilterByLogin != "" {
+			result.Users.FilterByLogin = cfg.Users.FilterByLogin
+		}
 		if cfg.Users.FilterGroupsByDn != "" {
 			result.Users.FilterGroupsByDn = cfg.Users.FilterGroupsByDn
 		}
+		if cfg.Users.FilterNestedGroupsByDn != "" {
+			result.Users.FilterNestedGroupsByDn = cfg.Users.FilterNestedGroupsByDn
+		}
+		if cfg.Users.FilterAll != "" {
+			result.Users.FilterAll = cfg.Users.FilterAll
+		}
 	}
 
 	if cfg.Groups != nil {
@@ -150,6 +222,15 @@ func populateConfig(cfg *Config) *Config {
 		if cfg.Groups.FilterMembersByDn != "" {
 			result.Groups.FilterMembersByDn = cfg.Groups.FilterMembersByDn
 		}
+		if cfg.Groups.FilterMemberById != "" {
+			result.Groups.FilterMemberById = cfg.Groups.FilterMemberById
+		}
+		if cfg.Groups.FilterNestedMembersByDn != "" {
+			result.Groups.FilterNestedMembersByDn = cfg.Groups.FilterNestedMembersByDn
+		}
+		if cfg.Groups.FilterAll != "" {
+			result.Groups.FilterAll = cfg.Groups.FilterAll
+		}
 	}
 
 	return result
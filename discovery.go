@@ -0,0 +1,122 @@
+package adc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Domain controllers discovery through DNS SRV records.
+type DiscoveryConfig struct {
+	// AD domain name, e.g. 'company.com'.
+	Domain string `json:"domain"`
+	// AD site name. Site domain controllers are looked up first if provided.
+	Site string `json:"site"`
+	// URL scheme of discovered servers: 'ldap' or 'ldaps'. Defaults to 'ldap'.
+	Scheme string `json:"scheme"`
+	// Port of discovered servers. Defaults to SRV record port for 'ldap' and 636 for 'ldaps' scheme.
+	Port int `json:"port"`
+}
+
+// DNS resolver used for domain controllers discovery. Implemented by *net.Resolver.
+type Resolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// Specifies custom DNS resolver for domain controllers discovery.
+func WithResolver(r Resolver) Option {
+	return func(cl *Client) { cl.resolver = r }
+}
+
+// Returns domain controllers URLs discovered by DNS SRV records ordered by priority and weight.
+// Site-specific records are used if site is provided and they exist, domain-wide records otherwise.
+func (cl *Client) discoverURLs(ctx context.Context) ([]string, error) {
+	cfg := cl.Config.Discovery
+	if cfg.Domain == "" {
+		return nil, errors.New("discovery domain is required")
+	}
+
+	var records []*net.SRV
+	if cfg.Site != "" {
+		name := fmt.Sprintf("_ldap._tcp.%s._sites.dc._msdcs.%s", cfg.Site, cfg.Domain)
+		_, siteRecords, err := cl.resolver.LookupSRV(ctx, "", "", name)
+		if err != nil {
+			cl.logger.Debugf("Failed to discover site '%s' domain controllers: %s", cfg.Site, err.Error())
+		}
+		records = siteRecords
+	}
+	if len(records) == 0 {
+		_, domainRecords, err := cl.resolver.LookupSRV(ctx, "ldap", "tcp", cfg.Domain)
+		if err != nil {
+			return nil, fmt.Errorf("can't discover domain controllers: %w", err)
+		}
+		records = domainRecords
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("no domain controllers found for domain '%s'", cfg.Domain)
+	}
+
+	scheme := cfg.Scheme
+	if scheme == "" {
+		scheme = "ldap"
+	}
+	if scheme != "ldap" && scheme != "ldaps" {
+		return nil, fmt.Errorf("unsupported discovery scheme '%s'", scheme)
+	}
+
+	var result []string
+	for _, r := range orderSRV(records) {
+		port := int(r.Port)
+		switch {
+		case cfg.Port != 0:
+			port = cfg.Port
+		case scheme == "ldaps":
+			port = 636
+		}
+		host := strings.TrimSuffix(r.Target, ".")
+		result = append(result, scheme+"://"+net.JoinHostPort(host, strconv.Itoa(port)))
+	}
+	cl.logger.Debugf("Discovered domain controllers: %v", result)
+	return result, nil
+}
+
+// Orders SRV records by priority and randomly by weight within the same priority as described in RFC 2782.
+func orderSRV(records []*net.SRV) []*net.SRV {
+	sorted := slices.Clone(records)
+	slices.SortStableFunc(sorted, func(a, b *net.SRV) int { return int(a.Priority) - int(b.Priority) })
+
+	result := make([]*net.SRV, 0, len(sorted))
+	for len(sorted) > 0 {
+		end := 1
+		for end < len(sorted) && sorted[end].Priority == sorted[0].Priority {
+			end++
+		}
+		group := sorted[:end]
+		sorted = sorted[end:]
+
+		for len(group) > 0 {
+			total := 0
+			for _, r := range group {
+				total += int(r.Weight)
+			}
+			i := 0
+			if total > 0 {
+				n := rand.Intn(total + 1)
+				for sum := 0; i < len(group)-1; i++ {
+					sum += int(group[i].Weight)
+					if sum >= n {
+						break
+					}
+				}
+			}
+			result = append(result, group[i])
+			group = slices.Delete(group, i, i+1)
+		}
+	}
+	return result
+}
@@ -0,0 +1,103 @@
+package adc
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+var (
+	// Entry not found.
+	ErrNotFound = errors.New("not found")
+	// Search returned more than one entry when single entry is expected.
+	ErrTooManyEntries = errors.New("too many entries found")
+	// Entry or attribute value already exists.
+	ErrAlreadyExists = errors.New("already exists")
+	// Bind account doesn't have enough permissions for the operation.
+	ErrInsufficientAccess = errors.New("insufficient access rights")
+	// Client isn't connected to AD server or the connection is closed.
+	ErrNotConnected = errors.New("not connected")
+	// Password doesn't meet domain password policy requirements.
+	ErrPasswordPolicy = errors.New("password doesn't meet password policy requirements")
+	// Provided old password is wrong.
+	ErrWrongPassword = errors.New("wrong old password")
+	// Operation requires secure (LDAPS or StartTLS) connection.
+	ErrInsecureConnection = errors.New("secure connection is required")
+	// Some of desired group members can't be found.
+	ErrUnresolvedMembers = errors.New("unresolved members")
+)
+
+// LDAP operation error returned by the server. Wraps original ldap error, so both
+// errors.As(err, &ldapErr) with *ldap.Error and errors.Is with package sentinel errors work.
+type LDAPError struct {
+	// Operation name: bind, search, add, modify or delete.
+	Op string
+	// DN of the operation target entry or search base.
+	DN string
+	// LDAP result code.
+	ResultCode uint16
+	// Matched DN returned by the server, if any.
+	MatchedDN string
+	Err       error
+}
+
+func (e *LDAPError) Error() string {
+	return fmt.Sprintf("%s '%s': %s", e.Op, e.DN, e.Err.Error())
+}
+
+func (e *LDAPError) Unwrap() error {
+	return e.Err
+}
+
+// Reports whether error matches package sentinel error by LDAP result code.
+// 'No such object' result of search means that search base doesn't exist, so it isn't reported as 'ErrNotFound'.
+func (e *LDAPError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.ResultCode == ldap.LDAPResultNoSuchObject && e.Op != "search"
+	case ErrAlreadyExists:
+		return e.ResultCode == ldap.LDAPResultEntryAlreadyExists ||
+			e.ResultCode == ldap.LDAPResultAttributeOrValueExists
+	case ErrInsufficientAccess:
+		return e.ResultCode == ldap.LDAPResultInsufficientAccessRights
+	case ErrNotConnected:
+		return e.ResultCode == ldap.ErrorNetwork
+	}
+	return false
+}
+
+// Wraps ldap error into LDAPError. Other errors, e.g. context errors, are returned as is.
+func wrapLDAPError(op, dn string, err error) error {
+	var lerr *ldap.Error
+	if !errors.As(err, &lerr) {
+		return err
+	}
+	return &LDAPError{
+		Op:         op,
+		DN:         dn,
+		ResultCode: lerr.ResultCode,
+		MatchedDN:  lerr.MatchedDN,
+		Err:        err,
+	}
+}
+
+// Converts AD password modification errors to package errors.
+// AD reports the reason in the diagnostic message as a Windows error code.
+func passwordError(err error) error {
+	var lerr *ldap.Error
+	if !errors.As(err, &lerr) {
+		return err
+	}
+	msg := strings.ToUpper(lerr.Err.Error())
+	switch {
+	case strings.Contains(msg, "0000052D"):
+		return fmt.Errorf("%w: %w", ErrPasswordPolicy, err)
+	case strings.Contains(msg, "00000056"):
+		return fmt.Errorf("%w: %w", ErrWrongPassword, err)
+	case strings.Contains(msg, "0000001F") && lerr.ResultCode == ldap.LDAPResultUnwillingToPerform:
+		return fmt.Errorf("%w: %w", ErrInsecureConnection, err)
+	}
+	return err
+}
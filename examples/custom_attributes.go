@@ -32,9 +32,6 @@ func mainCustomAttributes() {
 	if err != nil {
 		panic(err)
 	}
-	if user == nil {
-		panic("User not found")
-	}
 
 	fmt.Println(user.GetStringAttribute("manager"))
 
@@ -47,8 +44,5 @@ func mainCustomAttributes() {
 	if err != nil {
 		panic(err)
 	}
-	if user2 == nil {
-		panic("User not found")
-	}
 	fmt.Println(user2.GetStringAttribute("manager"))
 }
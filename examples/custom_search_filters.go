@@ -39,8 +39,5 @@ func mainCustomSearchFilters() {
 	if err != nil {
 		panic(err)
 	}
-	if user == nil {
-		panic("User not found")
-	}
 	fmt.Println(user)
 }
@@ -1,6 +1,7 @@
 package examples
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/dlampsi/adc"
@@ -47,7 +48,8 @@ func mainGroups() {
 
 	/* -------------- Add group members -------------- */
 
-	added, err := cl.AddGroupMembers("exampleGroupId", "newUserId1", "newUserId2", "newUserId3")
+	// Members can be users, groups or computers provided by ID or DN.
+	added, err := cl.AddGroupMembers("exampleGroupId", "newUserId1", "nestedGroupId", "CN=host1,CN=Computers,DC=company,DC=com")
 	if err != nil {
 		panic(err)
 	}
@@ -61,6 +63,20 @@ func mainGroups() {
 	}
 	fmt.Printf("Deleted %d users from group members", deleted)
 
+	/* -------------- Sync group members -------------- */
+
+	// Adds missing and removes extra members, so group members match provided list.
+	report, err := cl.SyncGroupMembers(adc.SyncGroupMembersArgs{
+		GroupId: "exampleGroupId",
+		Members: []string{"userId1", "userId3"},
+		DryRun:  true,
+	})
+	// Sync is refused if some members aren't found, but dry-run report still lists planned changes.
+	if err != nil && !errors.Is(err, adc.ErrUnresolvedMembers) {
+		panic(err)
+	}
+	fmt.Printf("Would add %v and remove %v, unresolved: %v", report.Added, report.Removed, report.Unresolved)
+
 	/* -------------- Delete members -------------- */
 
 	if err := cl.DeleteGroup(group.DN); err != nil {
@@ -0,0 +1,49 @@
+package examples
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dlampsi/adc"
+	"github.com/dlampsi/adc/httpauth"
+)
+
+func mainHttpAuth() {
+	cfg := &adc.Config{
+		URL: "ldaps://my.ad.site:636",
+		Bind: &adc.BindAccount{
+			DN:       "CN=admin,DC=company,DC=com",
+			Password: "***",
+		},
+		SearchBase: "OU=default,DC=company,DC=com",
+	}
+
+	cl := adc.New(cfg)
+
+	if err := cl.Connect(); err != nil {
+		panic(err)
+	}
+
+	// Successful authentications are cached, so AD isn't requested on every request.
+	auth := httpauth.New(cl, httpauth.WithRealm("portal"), httpauth.WithCacheTTL(5*time.Minute))
+
+	hello := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := httpauth.UserFromContext(r.Context())
+		fmt.Fprintf(w, "Hello, %s", user.Id)
+	})
+
+	mux := http.NewServeMux()
+	// Any authenticated user.
+	mux.Handle("/", auth.Handler(hello))
+	// Members of 'admins' group including nested groups, except 'contractors' group members.
+	mux.Handle("/admin", auth.Require(adc.AuthPolicy{
+		RequiredGroups: []string{"admins"},
+		DeniedGroups:   []string{"contractors"},
+		NestedGroups:   true,
+	})(hello))
+
+	if err := http.ListenAndServe(":8080", mux); err != nil {
+		panic(err)
+	}
+}
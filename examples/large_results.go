@@ -0,0 +1,56 @@
+package examples
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dlampsi/adc"
+)
+
+func mainLargeResults(ctx context.Context) {
+	cfg := &adc.Config{
+		URL: "ldaps://my.ad.site:636",
+		Bind: &adc.BindAccount{
+			DN:       "CN=admin,DC=company,DC=com",
+			Password: "***",
+		},
+		SearchBase: "OU=default,DC=company,DC=com",
+		// Entries are requested from AD by pages of this size.
+		PageSize: 500,
+	}
+
+	cl := adc.New(cfg)
+
+	if err := cl.Connect(); err != nil {
+		panic(err)
+	}
+
+	/* -------------- List -------------- */
+
+	// All users are fetched page by page and returned at once.
+	users, err := cl.ListUsers(adc.ListUsersArgs{
+		SearchBase:       "OU=staff,DC=company,DC=com",
+		SkipGroupsSearch: true,
+	})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(users))
+
+	/* -------------- Iterate -------------- */
+
+	// Pages are fetched lazily, so only one page is held in memory at once.
+	it := cl.IterUsers(ctx, adc.ListUsersArgs{SkipGroupsSearch: true})
+	defer it.Close()
+
+	for it.Next() {
+		user := it.User()
+		if user.Id == "exampleUserId" {
+			// Remaining pages are abandoned by Close().
+			break
+		}
+	}
+	if err := it.Err(); err != nil {
+		panic(err)
+	}
+}
@@ -29,3 +29,31 @@ func mainReconnect(ctx context.Context) {
 		panic(err)
 	}
 }
+
+func mainAutoReconnect() {
+	cfg := &adc.Config{
+		URL: "ldaps://my.ad.site:636",
+		Bind: &adc.BindAccount{
+			DN:       "CN=admin,DC=company,DC=com",
+			Password: "***",
+		},
+		SearchBase: "OU=default,DC=company,DC=com",
+		// Redial lost connections with up to 5 attempts and delays from 1 to 30 seconds.
+		Reconnect: &adc.ReconnectConfig{
+			MaxAttempts: 5,
+			MinBackoff:  time.Second,
+			MaxBackoff:  30 * time.Second,
+		},
+	}
+
+	cl := adc.New(cfg)
+
+	if err := cl.Connect(); err != nil {
+		panic(err)
+	}
+
+	// Searches are retried transparently if connection is lost.
+	if _, err := cl.GetUser(adc.GetUserArgs{Id: "user1"}); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,33 @@
+package examples
+
+import (
+	"github.com/dlampsi/adc"
+)
+
+func mainTLS() {
+	cfg := &adc.Config{
+		URL: "ldap://my.ad.site:389",
+		// Upgrade plain connection with StartTLS before bind.
+		StartTLS: true,
+		TLS: &adc.TLSConfig{
+			// Verify server certificate with internal CA instead of disabling verification.
+			CAFile: "/etc/ssl/company-ca.pem",
+			// Client certificate for mutual TLS.
+			CertFile:   "/etc/ssl/client.pem",
+			KeyFile:    "/etc/ssl/client.key",
+			ServerName: "dc.company.com",
+			MinVersion: "1.2",
+		},
+		Bind: &adc.BindAccount{
+			DN:       "CN=admin,DC=company,DC=com",
+			Password: "***",
+		},
+		SearchBase: "OU=default,DC=company,DC=com",
+	}
+
+	cl := adc.New(cfg)
+
+	if err := cl.Connect(); err != nil {
+		panic(err)
+	}
+}
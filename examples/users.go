@@ -1,6 +1,7 @@
 package examples
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/dlampsi/adc"
@@ -41,12 +42,12 @@ func mainUsers() {
 		Id: "exampleUserId",
 	}
 	user, err := cl.GetUser(getReq)
+	if errors.Is(err, adc.ErrNotFound) {
+		panic("User not found")
+	}
 	if err != nil {
 		panic(err)
 	}
-	if user == nil {
-		panic("User not found")
-	}
 	fmt.Println(user)
 
 	/* -------------- Delete -------------- */
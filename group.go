@@ -1,15 +1,16 @@
 package adc
 
 import (
+	"context"
 	"errors"
 	"fmt"
-	"slices"
-	"sync"
+	"time"
 
 	"github.com/go-ldap/ldap/v3"
 )
 
 // Active Direcotry group.
+// Single-valued attributes are stored as string and multi-valued attributes as []string.
 type Group struct {
 	DN         string                 `json:"dn"`
 	Id         string                 `json:"id"`
@@ -19,21 +20,42 @@ type Group struct {
 
 // Active Direcotry member info.
 type GroupMember struct {
-	DN string `json:"dn"`
-	Id string `json:"id"`
+	DN   string     `json:"dn"`
+	Id   string     `json:"id"`
+	Type ObjectType `json:"type"`
+	// Member is inherited through nested groups and isn't a direct member of the group.
+	Inherited bool `json:"inherited"`
 }
 
-// Returns string attribute by attribute name.
+// Returns string attribute by attribute name. Returns first value for multi-valued attribute.
 // Returns empty string if attribute not exists or it can't be covnerted to string.
 func (g *Group) GetStringAttribute(name string) string {
-	for att, val := range g.Attributes {
-		if att == name {
-			if s, ok := val.(string); ok {
-				return s
-			}
-		}
-	}
-	return ""
+	return getStringAttribute(g.Attributes, name)
+}
+
+// Returns all values of string attribute by attribute name.
+// Returns nil if attribute not exists or it can't be covnerted to strings.
+func (g *Group) GetStringsAttribute(name string) []string {
+	return getStringsAttribute(g.Attributes, name)
+}
+
+// Returns raw bytes of attribute listed in client config binary attributes.
+// Returns first value for multi-valued attribute and nil if attribute not exists or it isn't binary.
+func (g *Group) GetBytesAttribute(name string) []byte {
+	return getBytesAttribute(g.Attributes, name)
+}
+
+// Returns integer attribute by attribute name, e.g. 'userAccountControl'.
+// Returns false if attribute not exists or it can't be converted to integer.
+func (g *Group) GetIntAttribute(name string) (int64, bool) {
+	return getIntAttribute(g.Attributes, name)
+}
+
+// Returns time attribute by attribute name. Both Windows FILETIME ('pwdLastSet', 'accountExpires', etc.)
+// and GeneralizedTime ('whenCreated', 'whenChanged', etc.) formats are supported.
+// Returns zero time for AD 'never' values and false if attribute not exists or it can't be converted to time.
+func (g *Group) GetTimeAttribute(name string) (time.Time, bool) {
+	return getTimeAttribute(g.Attributes, name)
 }
 
 type GetGroupArgs struct {
@@ -47,6 +69,8 @@ type GetGroupArgs struct {
 	Attributes []string `json:"attributes"`
 	// Skip search of group members data. Can improve request time.
 	SkipMembersSearch bool `json:"skip_members_search"`
+	// Resolve members inherited through nested groups in addition to direct members.
+	ResolveNestedMembers bool `json:"resolve_nested_members"`
 }
 
 func (args GetGroupArgs) Validate() error {
@@ -56,7 +80,13 @@ func (args GetGroupArgs) Validate() error {
 	return nil
 }
 
+// Same as GetGroupContext with background context.
 func (cl *Client) GetGroup(args GetGroupArgs) (*Group, error) {
+	return cl.GetGroupContext(context.Background(), args)
+}
+
+// Returns group found by provided args. Returns 'ErrNotFound' error if group not found.
+func (cl *Client) GetGroupContext(ctx context.Context, args GetGroupArgs) (*Group, error) {
 	if err := args.Validate(); err != nil {
 		return nil, err
 	}
@@ -82,27 +112,29 @@ func (cl *Client) GetGroup(args GetGroupArgs) (*Group, error) {
 		req.Attributes = args.Attributes
 	}
 
-	entry, err := cl.searchEntry(req)
+	entry, err := cl.searchEntry(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	if entry == nil {
-		return nil, nil
+		return nil, fmt.Errorf("group %w", ErrNotFound)
 	}
 
+	return cl.groupFromEntry(ctx, entry, args)
+}
+
+// Maps ldap entry to group and fetches group members if needed.
+func (cl *Client) groupFromEntry(ctx context.Context, entry *ldap.Entry, args GetGroupArgs) (*Group, error) {
 	result := &Group{
 		DN:         entry.DN,
-		Id:         entry.GetAttributeValue(cl.Config.Groups.IdAttribute),
-		Attributes: make(map[string]interface{}, len(entry.Attributes)),
-	}
-	for _, a := range entry.Attributes {
-		result.Attributes[a.Name] = entry.GetAttributeValue(a.Name)
+		Id:         entryAttributeValue(entry, cl.Config.Groups.IdAttribute),
+		Attributes: cl.entryAttributes(entry),
 	}
 
 	if !args.SkipMembersSearch {
-		members, err := cl.getGroupMembers(entry.DN)
+		members, err := cl.getGroupMembers(ctx, entry.DN, args.ResolveNestedMembers)
 		if err != nil {
-			return nil, fmt.Errorf("can't get group members: %s", err.Error())
+			return nil, fmt.Errorf("can't get group members: %w", err)
 		}
 		result.Members = members
 	}
@@ -110,195 +142,86 @@ func (cl *Client) GetGroup(args GetGroupArgs) (*Group, error) {
 	return result, nil
 }
 
-func (cl *Client) getGroupMembers(dn string) ([]GroupMember, error) {
-	req := &ldap.SearchRequest{
-		BaseDN:       cl.Config.Users.SearchBase,
-		Scope:        ldap.ScopeWholeSubtree,
-		DerefAliases: ldap.NeverDerefAliases,
-		TimeLimit:    int(cl.Config.Timeout.Seconds()),
-		Filter:       fmt.Sprintf(cl.Config.Groups.FilterMembersByDn, ldap.EscapeFilter(dn)),
-		Attributes:   []string{cl.Config.Users.IdAttribute},
-	}
-	entries, err := cl.searchEntries(req)
-	if err != nil {
-		return nil, err
-	}
-	var result []GroupMember
-	for _, e := range entries {
-		result = append(result, GroupMember{
-			DN: e.DN,
-			Id: e.GetAttributeValue(cl.Config.Groups.IdAttribute),
-		})
-	}
-	return result, nil
+type ListGroupsArgs struct {
+	// Optional LDAP filter to search entries. Client config groups filter used if not provided.
+	Filter string `json:"filter"`
+	// Optional base OU to search groups. Overwrites groups search base in client config.
+	SearchBase string `json:"search_base"`
+	// Optional group attributes to overwrite attributes in client config.
+	Attributes []string `json:"attributes"`
+	// Skip search of groups members data. Members are requested separately for each group,
+	// so it's recommended for large result sets.
+	SkipMembersSearch bool `json:"skip_members_search"`
+	// Resolve members inherited through nested groups in addition to direct members.
+	ResolveNestedMembers bool `json:"resolve_nested_members"`
 }
 
-// Returns list of group members DNs.
-func (g *Group) MembersDn() []string {
-	var result []string
-	for _, m := range g.Members {
-		result = append(result, m.DN)
+func (args ListGroupsArgs) getGroupArgs() GetGroupArgs {
+	return GetGroupArgs{
+		Attributes:           args.Attributes,
+		SkipMembersSearch:    args.SkipMembersSearch,
+		ResolveNestedMembers: args.ResolveNestedMembers,
 	}
-	return result
 }
 
-// Returns list of group members IDs.
-func (g *Group) MembersId() []string {
-	var result []string
-	for _, m := range g.Members {
-		result = append(result, m.Id)
-	}
-	return result
+// Same as ListGroupsContext with background context.
+func (cl *Client) ListGroups(args ListGroupsArgs) ([]*Group, error) {
+	return cl.ListGroupsContext(context.Background(), args)
 }
 
-// Adds provided accounts IDs to provided group members. Returns number of addedd accounts.
-func (cl *Client) AddGroupMembers(groupId string, membersIds ...string) (int, error) {
-	group, err := cl.GetGroup(GetGroupArgs{Id: groupId})
+// Returns all groups found by provided args. Uses paged search, so result isn't limited by server page size.
+func (cl *Client) ListGroupsContext(ctx context.Context, args ListGroupsArgs) ([]*Group, error) {
+	entries, err := cl.searchEntries(ctx, cl.listGroupsRequest(args))
 	if err != nil {
-		return 0, fmt.Errorf("can't get group: %s", err.Error())
-	}
-	if group == nil {
-		return 0, fmt.Errorf("group '%s' not found by ID", groupId)
-	}
-
-	ch := make(chan string, len(membersIds))
-	errCh := make(chan error, len(membersIds))
-	wg := &sync.WaitGroup{}
-
-	for _, id := range membersIds {
-		wg.Add(1)
-		go func(userId string, ch chan<- string, errCh chan<- error, wg *sync.WaitGroup) {
-			defer wg.Done()
-			user, err := cl.GetUser(GetUserArgs{Id: userId})
-			if err != nil {
-				errCh <- fmt.Errorf("can't get account '%s': %s", userId, err.Error())
-				return
-			}
-			if user == nil {
-				cl.logger.Debugf("Account '%s' being added to '%s' wasn't found",
-					userId, groupId)
-				return
-			}
-			if user.IsGroupMember(groupId) {
-				cl.logger.Debugf("The adding account '%s' is already a member of the group '%s'",
-					userId, groupId)
-				return
-			}
-			ch <- user.DN
-		}(id, ch, errCh, wg)
+		return nil, err
 	}
-	wg.Wait()
-	close(errCh)
-	close(ch)
 
-	for err := range errCh {
+	result := make([]*Group, 0, len(entries))
+	for _, entry := range entries {
+		group, err := cl.groupFromEntry(ctx, entry, args.getGroupArgs())
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
+		result = append(result, group)
 	}
-
-	var toAdd []string
-	for dn := range ch {
-		toAdd = append(toAdd, dn)
-	}
-	if len(toAdd) == 0 {
-		return 0, nil
-	}
-
-	newMembers := popAddGroupMembers(group, toAdd)
-
-	cl.logger.Debugf("Adding new group members to '%s'; Old count: %d; New count: %d",
-		groupId, len(group.MembersId()), len(newMembers))
-
-	if err := cl.updateAttribute(group.DN, "member", newMembers); err != nil {
-		return 0, err
-	}
-
-	return len(toAdd), nil
-}
-
-func popAddGroupMembers(g *Group, toAdd []string) []string {
-	if len(toAdd) == 0 {
-		return g.MembersDn()
-	}
-	result := make([]string, 0, len(g.Members)+len(toAdd))
-	result = append(result, g.MembersDn()...)
-	result = append(result, toAdd...)
-	return result
+	return result, nil
 }
 
-// Deletes provided accounts IDs from provided group members. Returns number of deleted from group members.
-func (cl *Client) DeleteGroupMembers(groupId string, membersIds ...string) (int, error) {
-	group, err := cl.GetGroup(GetGroupArgs{Id: groupId})
-	if err != nil {
-		return 0, fmt.Errorf("can't get group: %s", err.Error())
-	}
-	if group == nil {
-		return 0, fmt.Errorf("group '%s' not found by ID", groupId)
-	}
-
-	ch := make(chan string, len(membersIds))
-	errCh := make(chan error, len(membersIds))
-	wg := &sync.WaitGroup{}
-
-	for _, id := range membersIds {
-		wg.Add(1)
-		go func(userId string, ch chan<- string, errCh chan<- error, wg *sync.WaitGroup) {
-			defer wg.Done()
-			user, err := cl.GetUser(GetUserArgs{Id: userId})
-			if err != nil {
-				errCh <- fmt.Errorf("can't get account '%s': %s", userId, err.Error())
-				return
-			}
-			if user == nil {
-				cl.logger.Debugf("Account '%s' being deleted from '%s' wasn't found",
-					userId, groupId)
-				return
-			}
-			if !user.IsGroupMember(groupId) {
-				cl.logger.Debugf("The deleting account '%s' already isn't a member of the group '%s'",
-					userId, groupId)
-				return
-			}
-			ch <- user.DN
-		}(id, ch, errCh, wg)
+func (cl *Client) listGroupsRequest(args ListGroupsArgs) *ldap.SearchRequest {
+	req := &ldap.SearchRequest{
+		BaseDN:       cl.Config.Groups.SearchBase,
+		Scope:        ldap.ScopeWholeSubtree,
+		DerefAliases: ldap.NeverDerefAliases,
+		TimeLimit:    int(cl.Config.Timeout.Seconds()),
+		Filter:       cl.Config.Groups.FilterAll,
+		Attributes:   cl.Config.Groups.Attributes,
 	}
-	wg.Wait()
-	close(errCh)
-	close(ch)
-
-	for err := range errCh {
-		if err != nil {
-			return 0, err
-		}
+	if args.Filter != "" {
+		req.Filter = args.Filter
 	}
-
-	var toDel []string
-	for dn := range ch {
-		toDel = append(toDel, dn)
+	if args.SearchBase != "" {
+		req.BaseDN = args.SearchBase
 	}
-	if len(toDel) == 0 {
-		return 0, nil
+	if args.Attributes != nil {
+		req.Attributes = args.Attributes
 	}
+	return req
+}
 
-	newMembers := popDelGroupMembers(group, toDel)
-
-	cl.logger.Debugf("Deleting members from group '%s'; Old count: %d; New count: %d",
-		groupId, len(group.MembersId()), len(newMembers))
-
-	if err := cl.updateAttribute(group.DN, "member", newMembers); err != nil {
-		return 0, err
+// Returns list of group members DNs.
+func (g *Group) MembersDn() []string {
+	var result []string
+	for _, m := range g.Members {
+		result = append(result, m.DN)
 	}
-
-	return len(toDel), nil
+	return result
 }
 
-func popDelGroupMembers(g *Group, toDel []string) []string {
-	result := []string{}
-	for _, memberDN := range g.MembersDn() {
-		if !slices.Contains(toDel, memberDN) {
-			result = append(result, memberDN)
-		}
+// Returns list of group members IDs.
+func (g *Group) MembersId() []string {
+	var result []string
+	for _, m := range g.Members {
+		result = append(result, m.Id)
 	}
 	return result
 }
@@ -315,8 +238,13 @@ func (args CreateGroupArgs) Validate() error {
 	return nil
 }
 
-// Creates a new group.
+// Same as CreateGroupContext with background context.
 func (cl *Client) CreateGroup(args CreateGroupArgs) error {
+	return cl.CreateGroupContext(context.Background(), args)
+}
+
+// Creates a new group.
+func (cl *Client) CreateGroupContext(ctx context.Context, args CreateGroupArgs) error {
 	if err := args.Validate(); err != nil {
 		return fmt.Errorf("Bad request: %w", err)
 	}
@@ -344,18 +272,23 @@ func (cl *Client) CreateGroup(args CreateGroupArgs) error {
 
 	entryDn := fmt.Sprintf("CN=%s,%s", args.Id, cl.Config.Groups.SearchBase)
 
-	return cl.createEntry(entryDn, attributes)
+	return cl.createEntry(ctx, entryDn, attributes)
 }
 
-// Deletes a group by ID.
+// Same as DeleteGroupContext with background context.
 func (cl *Client) DeleteGroup(groupId string) error {
-	entry, err := cl.GetGroup(GetGroupArgs{Id: groupId})
-	if err != nil {
-		return fmt.Errorf("Failed to get group: %w", err)
-	}
-	if entry == nil {
+	return cl.DeleteGroupContext(context.Background(), groupId)
+}
+
+// Deletes a group by ID.
+func (cl *Client) DeleteGroupContext(ctx context.Context, groupId string) error {
+	entry, err := cl.GetGroupContext(ctx, GetGroupArgs{Id: groupId, SkipMembersSearch: true})
+	if errors.Is(err, ErrNotFound) {
 		cl.logger.Debugf("Group '%s' already doesn't exist", groupId)
 		return nil
 	}
-	return cl.deleteEntry(entry.DN)
+	if err != nil {
+		return fmt.Errorf("Failed to get group: %w", err)
+	}
+	return cl.deleteEntry(ctx, entry.DN)
 }
@@ -0,0 +1,215 @@
+// Package httpauth provides HTTP Basic authentication middleware backed by Active Directory.
+package httpauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/dlampsi/adc"
+)
+
+// Authenticates users by login and password. Implemented by *adc.Client.
+type Authenticator interface {
+	AuthenticateWithPolicy(ctx context.Context, login, password string, policy adc.AuthPolicy) (*adc.AuthResult, error)
+}
+
+// HTTP Basic authentication middleware.
+type Middleware struct {
+	auth   Authenticator
+	realm  string
+	ttl    time.Duration
+	logger adc.Logger
+	// Random key of credentials hashes in cache.
+	key []byte
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cacheEntry
+}
+
+type cacheEntry struct {
+	user    *adc.User
+	expires time.Time
+}
+
+// Creates new middleware that validates credentials by provided authenticator.
+func New(auth Authenticator, opts ...Option) *Middleware {
+	m := &Middleware{
+		auth:   auth,
+		realm:  "Restricted",
+		logger: nopLogger{},
+		key:    make([]byte, sha256.Size),
+		cache:  make(map[[sha256.Size]byte]cacheEntry),
+	}
+	if _, err := rand.Read(m.key); err != nil {
+		panic(fmt.Sprintf("httpauth: can't generate cache key: %s", err.Error()))
+	}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+type Option func(*Middleware)
+
+// Specifies realm for 'WWW-Authenticate' header.
+func WithRealm(realm string) Option {
+	return func(m *Middleware) { m.realm = realm }
+}
+
+// Specifies how long successful authentications are cached. Caching is disabled by default.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(m *Middleware) { m.ttl = ttl }
+}
+
+// Specifies custom logger for middleware.
+func WithLogger(l adc.Logger) Option {
+	return func(m *Middleware) { m.logger = l }
+}
+
+// Returns handler that allows any authenticated user.
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return m.Require(adc.AuthPolicy{})(next)
+}
+
+// Returns middleware that allows authenticated users matching provided policy only.
+// Unauthenticated requests get 401 and requests of users blocked by the policy get 403 response.
+func (m *Middleware) Require(policy adc.AuthPolicy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			login, password, ok := r.BasicAuth()
+			if !ok || login == "" || password == "" {
+				m.unauthorized(w)
+				return
+			}
+
+			user, err := m.authenticate(r.Context(), login, password)
+			if err != nil {
+				var authErr *adc.AuthError
+				if errors.As(err, &authErr) {
+					m.logger.Debugf("Authentication of '%s' failed: %s", login, authErr.Reason)
+					m.unauthorized(w)
+					return
+				}
+				m.logger.Debugf("Authentication of '%s' failed: %s", login, err.Error())
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if decision := policy.Evaluate(user); !decision.Allowed {
+				m.logger.Debugf("User '%s' isn't authorized by '%s' rule", login, decision.Rule)
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userContextKey{}, user)))
+		})
+	}
+}
+
+// Authenticates user or returns cached user. User groups include nested groups,
+// so any route policy can be evaluated against cached user. Cache keeps its own copy of the user,
+// so handlers can't modify users of other requests.
+func (m *Middleware) authenticate(ctx context.Context, login, password string) (*adc.User, error) {
+	key := m.cacheKey(login, password)
+	if user := m.cached(key); user != nil {
+		return user, nil
+	}
+
+	result, err := m.auth.AuthenticateWithPolicy(ctx, login, password, adc.AuthPolicy{NestedGroups: true})
+	if err != nil {
+		return nil, err
+	}
+
+	if m.ttl > 0 {
+		m.mu.Lock()
+		now := time.Now()
+		for k, e := range m.cache {
+			if now.After(e.expires) {
+				delete(m.cache, k)
+			}
+		}
+		m.cache[key] = cacheEntry{user: copyUser(result.User), expires: now.Add(m.ttl)}
+		m.mu.Unlock()
+	}
+
+	return result.User, nil
+}
+
+func (m *Middleware) cached(key [sha256.Size]byte) *adc.User {
+	if m.ttl <= 0 {
+		return nil
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.cache[key]
+	if !ok {
+		return nil
+	}
+	if time.Now().After(e.expires) {
+		delete(m.cache, key)
+		return nil
+	}
+	return copyUser(e.user)
+}
+
+// Returns deep copy of user groups and attributes.
+func copyUser(u *adc.User) *adc.User {
+	c := *u
+	if u.Groups != nil {
+		c.Groups = append([]adc.UserGroup(nil), u.Groups...)
+	}
+	if u.Attributes != nil {
+		c.Attributes = make(map[string]interface{}, len(u.Attributes))
+		for name, value := range u.Attributes {
+			switch v := value.(type) {
+			case []string:
+				value = append([]string(nil), v...)
+			case []byte:
+				value = append([]byte(nil), v...)
+			case [][]byte:
+				values := make([][]byte, len(v))
+				for i := range v {
+					values[i] = append([]byte(nil), v[i]...)
+				}
+				value = values
+			}
+			c.Attributes[name] = value
+		}
+	}
+	return &c
+}
+
+// Returns cache key for credentials, so passwords aren't stored in memory as is.
+// Keyed hash can't be brute forced without the middleware key, which never leaves the process memory.
+func (m *Middleware) cacheKey(login, password string) [sha256.Size]byte {
+	h := hmac.New(sha256.New, m.key)
+	fmt.Fprintf(h, "%d:%s:%s", len(login), login, password)
+	var key [sha256.Size]byte
+	h.Sum(key[:0])
+	return key
+}
+
+func (m *Middleware) unauthorized(w http.ResponseWriter) {
+	w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Basic realm=%q, charset="UTF-8"`, m.realm))
+	http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+}
+
+type userContextKey struct{}
+
+// Returns authenticated user from request context. Returns nil if request wasn't authenticated.
+func UserFromContext(ctx context.Context) *adc.User {
+	user, _ := ctx.Value(userContextKey{}).(*adc.User)
+	return user
+}
+
+type nopLogger struct{}
+
+func (nopLogger) Debug(args ...interface{})                   {}
+func (nopLogger) Debugf(template string, args ...interface{}) {}
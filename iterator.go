@@ -0,0 +1,152 @@
+package adc
+
+import (
+	"context"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Iterates over search result entries fetching pages lazily.
+type entryIterator struct {
+	ctx   context.Context
+	pager *pager
+	page  []*ldap.Entry
+	entry *ldap.Entry
+	err   error
+}
+
+func (it *entryIterator) next() bool {
+	for len(it.page) == 0 {
+		if it.err != nil || it.pager.done {
+			return false
+		}
+		if err := it.ctx.Err(); err != nil {
+			return it.fail(err)
+		}
+		it.page, it.err = it.pager.next(it.ctx)
+	}
+	it.entry, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Stops iteration with provided error. Remaining pages are abandoned, so pager connection is released.
+func (it *entryIterator) fail(err error) bool {
+	it.err = err
+	it.page = nil
+	_ = it.pager.close()
+	return false
+}
+
+func (it *entryIterator) close() error {
+	it.page = nil
+	return it.pager.close()
+}
+
+// Iterator over users search result. Users are fetched from AD page by page while iterating,
+// so only a single page is held in memory at once.
+//
+//	it := cl.IterUsers(ctx, adc.ListUsersArgs{})
+//	defer it.Close()
+//	for it.Next() {
+//		user := it.User()
+//	}
+//	if err := it.Err(); err != nil {
+//		// Handle error
+//	}
+type UserIterator struct {
+	cl      *Client
+	entries *entryIterator
+	args    GetUserArgs
+	user    *User
+}
+
+// Returns iterator over users found by provided args.
+func (cl *Client) IterUsers(ctx context.Context, args ListUsersArgs) *UserIterator {
+	return &UserIterator{
+		cl: cl,
+		entries: &entryIterator{
+			ctx:   ctx,
+			pager: cl.newIterPager(cl.listUsersRequest(args)),
+		},
+		args: args.getUserArgs(),
+	}
+}
+
+// Advances iterator to the next user. Returns false when there are no more users or an error occurred.
+func (it *UserIterator) Next() bool {
+	it.user = nil
+	if !it.entries.next() {
+		return false
+	}
+	user, err := it.cl.userFromEntry(it.entries.ctx, it.entries.entry, it.args)
+	if err != nil {
+		return it.entries.fail(err)
+	}
+	it.user = user
+	return true
+}
+
+// Returns current user.
+func (it *UserIterator) User() *User {
+	return it.user
+}
+
+// Returns error occurred during iteration.
+func (it *UserIterator) Err() error {
+	return it.entries.err
+}
+
+// Stops iteration and abandons remaining pages on the server.
+func (it *UserIterator) Close() error {
+	return it.entries.close()
+}
+
+// Iterator over groups search result. Groups are fetched from AD page by page while iterating,
+// so only a single page is held in memory at once.
+type GroupIterator struct {
+	cl      *Client
+	entries *entryIterator
+	args    GetGroupArgs
+	group   *Group
+}
+
+// Returns iterator over groups found by provided args.
+func (cl *Client) IterGroups(ctx context.Context, args ListGroupsArgs) *GroupIterator {
+	return &GroupIterator{
+		cl: cl,
+		entries: &entryIterator{
+			ctx:   ctx,
+			pager: cl.newIterPager(cl.listGroupsRequest(args)),
+		},
+		args: args.getGroupArgs(),
+	}
+}
+
+// Advances iterator to the next group. Returns false when there are no more groups or an error occurred.
+func (it *GroupIterator) Next() bool {
+	it.group = nil
+	if !it.entries.next() {
+		return false
+	}
+	group, err := it.cl.groupFromEntry(it.entries.ctx, it.entries.entry, it.args)
+	if err != nil {
+		return it.entries.fail(err)
+	}
+	it.group = group
+	return true
+}
+
+// Returns current group.
+func (it *GroupIterator) Group() *Group {
+	return it.group
+}
+
+// Returns error occurred during iteration.
+func (it *GroupIterator) Err() error {
+	return it.entries.err
+}
+
+// Stops iteration and abandons remaining pages on the server.
+func (it *GroupIterator) Close() error {
+	return it.entries.close()
+}
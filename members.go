@@ -0,0 +1,655 @@
+package adc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Type of directory object, e.g. group member type.
+type ObjectType string
+
+const (
+	ObjectTypeUser                     ObjectType = "user"
+	ObjectTypeGroup                    ObjectType = "group"
+	ObjectTypeComputer                 ObjectType = "computer"
+	ObjectTypeContact                  ObjectType = "contact"
+	ObjectTypeForeignSecurityPrincipal ObjectType = "foreignSecurityPrincipal"
+)
+
+// Returns object type by its 'objectClass' values. Returns empty type if object type is unknown.
+func objectTypeFromClasses(classes []string) ObjectType {
+	// Order matters: computer object also has 'user' class.
+	for _, t := range []ObjectType{
+		ObjectTypeComputer,
+		ObjectTypeGroup,
+		ObjectTypeContact,
+		ObjectTypeForeignSecurityPrincipal,
+		ObjectTypeUser,
+	} {
+		if containsFold(classes, string(t)) {
+			return t
+		}
+	}
+	return ""
+}
+
+// Returns attributes to request for group members entries.
+func (cl *Client) memberAttributes() []string {
+	result := []string{"objectClass", cl.Config.Users.IdAttribute}
+	if !containsFold(result, cl.Config.Groups.IdAttribute) {
+		result = append(result, cl.Config.Groups.IdAttribute)
+	}
+	return result
+}
+
+// Maps ldap entry to group member. Member ID is taken from groups or users ID attribute depending on member type.
+func (cl *Client) memberFromEntry(entry *ldap.Entry) GroupMember {
+	t := objectTypeFromClasses(entry.GetAttributeValues("objectClass"))
+	idAttribute := cl.Config.Users.IdAttribute
+	if t == ObjectTypeGroup {
+		idAttribute = cl.Config.Groups.IdAttribute
+	}
+	return GroupMember{
+		DN:   entry.DN,
+		Id:   entryAttributeValue(entry, idAttribute),
+		Type: t,
+	}
+}
+
+func (cl *Client) getGroupMembers(ctx context.Context, dn string, nested bool) ([]GroupMember, error) {
+	membersDn, err := cl.getGroupMembersDn(ctx, dn)
+	if err != nil {
+		return nil, err
+	}
+	direct, err := cl.resolveGroupMembers(ctx, domainBase(dn), membersDn)
+	if err != nil {
+		return nil, err
+	}
+	if !nested {
+		return direct, nil
+	}
+
+	req := &ldap.SearchRequest{
+		BaseDN:       domainBase(dn),
+		Scope:        ldap.ScopeWholeSubtree,
+		DerefAliases: ldap.NeverDerefAliases,
+		TimeLimit:    int(cl.Config.Timeout.Seconds()),
+		Filter:       fmt.Sprintf(cl.Config.Groups.FilterNestedMembersByDn, ldap.EscapeFilter(dn)),
+		Attributes:   cl.memberAttributes(),
+	}
+	entries, err := cl.searchEntries(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	result := direct
+	for _, e := range entries {
+		isDirect := slices.ContainsFunc(direct, func(m GroupMember) bool {
+			return strings.EqualFold(m.DN, e.DN)
+		})
+		if isDirect {
+			continue
+		}
+		member := cl.memberFromEntry(e)
+		member.Inherited = true
+		result = append(result, member)
+	}
+	return result, nil
+}
+
+// Returns DNs from group 'member' attribute. Uses ranged retrieval ('member;range=X-Y'),
+// so result isn't limited by AD MaxValRange policy.
+func (cl *Client) getGroupMembersDn(ctx context.Context, dn string) ([]string, error) {
+	var result []string
+	start := 0
+	for {
+		req := &ldap.SearchRequest{
+			BaseDN:       dn,
+			Scope:        ldap.ScopeBaseObject,
+			DerefAliases: ldap.NeverDerefAliases,
+			TimeLimit:    int(cl.Config.Timeout.Seconds()),
+			Filter:       "(objectClass=*)",
+			Attributes:   []string{fmt.Sprintf("member;range=%d-*", start)},
+		}
+		entry, err := cl.searchEntry(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			return result, nil
+		}
+
+		var attr *ldap.EntryAttribute
+		for _, a := range entry.Attributes {
+			if name, _, _ := strings.Cut(a.Name, ";"); strings.EqualFold(name, "member") {
+				attr = a
+				break
+			}
+		}
+		if attr == nil {
+			return result, nil
+		}
+		result = append(result, attr.Values...)
+
+		end, ok := attributeRangeEnd(attr.Name)
+		if !ok || end < start {
+			return result, nil
+		}
+		start = end + 1
+	}
+}
+
+// Returns range end from ranged attribute name, e.g. 1499 for 'member;range=0-1499'.
+// Returns false for the last range ('member;range=1500-*') and attribute without range.
+func attributeRangeEnd(name string) (int, bool) {
+	_, rng, found := strings.Cut(strings.ToLower(name), ";range=")
+	if !found {
+		return 0, false
+	}
+	_, end, found := strings.Cut(rng, "-")
+	if !found || end == "*" {
+		return 0, false
+	}
+	v, err := strconv.Atoi(end)
+	if err != nil {
+		return 0, false
+	}
+	return v, true
+}
+
+// Number of members DNs resolved by single search request.
+const membersResolveBatchSize = 100
+
+// Searches entries by DNs in batches. Entries not found in provided base are skipped.
+func (cl *Client) searchEntriesByDn(ctx context.Context, baseDn string, dns []string, attributes []string) ([]*ldap.Entry, error) {
+	var result []*ldap.Entry
+	for i := 0; i < len(dns); i += membersResolveBatchSize {
+		batch := dns[i:min(i+membersResolveBatchSize, len(dns))]
+
+		var filter strings.Builder
+		filter.WriteString("(|")
+		for _, dn := range batch {
+			fmt.Fprintf(&filter, "(distinguishedName=%s)", ldap.EscapeFilter(dn))
+		}
+		filter.WriteString(")")
+
+		entries, err := cl.searchEntries(ctx, &ldap.SearchRequest{
+			BaseDN:       baseDn,
+			Scope:        ldap.ScopeWholeSubtree,
+			DerefAliases: ldap.NeverDerefAliases,
+			TimeLimit:    int(cl.Config.Timeout.Seconds()),
+			Filter:       filter.String(),
+			Attributes:   attributes,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, entries...)
+	}
+	return result, nil
+}
+
+// Resolves members IDs and types by their DNs. Members not found in provided base are returned without ID and type.
+func (cl *Client) resolveGroupMembers(ctx context.Context, baseDn string, membersDn []string) ([]GroupMember, error) {
+	entries, err := cl.searchEntriesByDn(ctx, baseDn, membersDn, cl.memberAttributes())
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[string]GroupMember, len(entries))
+	for _, e := range entries {
+		found[strings.ToLower(e.DN)] = cl.memberFromEntry(e)
+	}
+
+	var result []GroupMember
+	for _, dn := range membersDn {
+		member := found[strings.ToLower(dn)]
+		member.DN = dn
+		result = append(result, member)
+	}
+	return result, nil
+}
+
+// Returns domain naming context of provided DN, e.g. 'DC=company,DC=com' for 'CN=user,OU=staff,DC=company,DC=com'.
+// Returns provided DN as is if it has no domain components.
+func domainBase(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return dn
+	}
+	var dcs []string
+	for i := len(parsed.RDNs) - 1; i >= 0; i-- {
+		rdn := parsed.RDNs[i]
+		if len(rdn.Attributes) != 1 || !strings.EqualFold(rdn.Attributes[0].Type, "DC") {
+			break
+		}
+		dcs = append([]string{"DC=" + rdn.Attributes[0].Value}, dcs...)
+	}
+	if len(dcs) == 0 {
+		return dn
+	}
+	return strings.Join(dcs, ",")
+}
+
+// Reports whether provided value is a DN rather than an ID.
+func isDn(value string) bool {
+	dn, err := ldap.ParseDN(value)
+	return err == nil && len(dn.RDNs) > 0
+}
+
+// Returns DN of the object found by provided ID with members filter. Returns empty string if object not found.
+func (cl *Client) findMemberDn(ctx context.Context, baseDn string, id string) (string, error) {
+	entry, err := cl.searchEntry(ctx, &ldap.SearchRequest{
+		BaseDN:       baseDn,
+		Scope:        ldap.ScopeWholeSubtree,
+		DerefAliases: ldap.NeverDerefAliases,
+		TimeLimit:    int(cl.Config.Timeout.Seconds()),
+		Filter:       fmt.Sprintf(cl.Config.Groups.FilterMemberById, ldap.EscapeFilter(id)),
+		Attributes:   []string{"objectClass"},
+	})
+	if err != nil || entry == nil {
+		return "", err
+	}
+	return entry.DN, nil
+}
+
+// Returns DNs of provided members IDs or DNs mapped by provided values. Users, groups, computers and other
+// objects are supported. Members are searched in batches and not found members are skipped.
+func (cl *Client) findMembersDn(ctx context.Context, baseDn string, members []string) (map[string]string, error) {
+	var ids, dns []string
+	for _, m := range members {
+		switch {
+		case m == "":
+			return nil, errors.New("member ID or DN is required")
+		case isDn(m):
+			dns = append(dns, m)
+		default:
+			ids = append(ids, m)
+		}
+	}
+
+	result := make(map[string]string, len(members))
+	if err := cl.findMembersByDn(ctx, baseDn, dns, result); err != nil {
+		return nil, err
+	}
+	if err := cl.findMembersById(ctx, baseDn, ids, result); err != nil {
+		return nil, err
+	}
+
+	// Computer account names end with '$'. They're searched only if exact name isn't found,
+	// so user 'web01' doesn't clash with computer 'WEB01$'.
+	var computers []string
+	for _, id := range ids {
+		if _, ok := result[id]; !ok && !strings.HasSuffix(id, "$") {
+			computers = append(computers, id+"$")
+		}
+	}
+	found := make(map[string]string, len(computers))
+	if err := cl.findMembersById(ctx, baseDn, computers, found); err != nil {
+		return nil, err
+	}
+	for id, dn := range found {
+		result[strings.TrimSuffix(id, "$")] = dn
+	}
+
+	for _, m := range members {
+		if _, ok := result[m]; !ok {
+			cl.logger.Debugf("Member '%s' wasn't found", m)
+		}
+	}
+	return result, nil
+}
+
+// Searches members by DNs in batches and stores found DNs into result mapped by provided DNs.
+func (cl *Client) findMembersByDn(ctx context.Context, baseDn string, dns []string, result map[string]string) error {
+	entries, err := cl.searchEntriesByDn(ctx, baseDn, dns, []string{"objectClass"})
+	if err != nil {
+		return err
+	}
+	found := make(map[string]string, len(entries))
+	for _, e := range entries {
+		found[strings.ToLower(e.DN)] = e.DN
+	}
+	for _, dn := range dns {
+		if foundDn, ok := found[strings.ToLower(dn)]; ok {
+			result[dn] = foundDn
+		}
+	}
+	return nil
+}
+
+// Searches members by IDs in batches and stores found DNs into result mapped by provided IDs.
+// Found entries are matched to IDs by users and groups ID attributes. If members filter matches entries
+// by other attributes, IDs of the batch that weren't matched are searched one by one.
+func (cl *Client) findMembersById(ctx context.Context, baseDn string, ids []string, result map[string]string) error {
+	for i := 0; i < len(ids); i += membersResolveBatchSize {
+		batch := ids[i:min(i+membersResolveBatchSize, len(ids))]
+
+		var filter strings.Builder
+		filter.WriteString("(|")
+		for _, id := range batch {
+			fmt.Fprintf(&filter, cl.Config.Groups.FilterMemberById, ldap.EscapeFilter(id))
+		}
+		filter.WriteString(")")
+
+		entries, err := cl.searchEntries(ctx, &ldap.SearchRequest{
+			BaseDN:       baseDn,
+			Scope:        ldap.ScopeWholeSubtree,
+			DerefAliases: ldap.NeverDerefAliases,
+			TimeLimit:    int(cl.Config.Timeout.Seconds()),
+			Filter:       filter.String(),
+			Attributes:   cl.memberAttributes(),
+		})
+		if err != nil {
+			return err
+		}
+
+		matched := make(map[*ldap.Entry]bool, len(entries))
+		var unmatched []string
+		for _, id := range batch {
+			var dn string
+			for _, e := range entries {
+				if !cl.entryHasId(e, id) {
+					continue
+				}
+				if dn != "" && !strings.EqualFold(dn, e.DN) {
+					return fmt.Errorf("can't get member '%s': %w", id, ErrTooManyEntries)
+				}
+				dn = e.DN
+				matched[e] = true
+			}
+			if dn == "" {
+				unmatched = append(unmatched, id)
+				continue
+			}
+			result[id] = dn
+		}
+		if len(matched) == len(entries) {
+			continue
+		}
+
+		for _, id := range unmatched {
+			dn, err := cl.findMemberDn(ctx, baseDn, id)
+			if err != nil {
+				return fmt.Errorf("can't get member '%s': %w", id, err)
+			}
+			if dn != "" {
+				result[id] = dn
+			}
+		}
+	}
+	return nil
+}
+
+// Reports whether entry has provided ID in users or groups ID attribute.
+func (cl *Client) entryHasId(entry *ldap.Entry, id string) bool {
+	for _, attr := range []string{cl.Config.Users.IdAttribute, cl.Config.Groups.IdAttribute} {
+		if containsFold(entry.GetAttributeValues(attr), id) {
+			return true
+		}
+	}
+	return false
+}
+
+// Returns found members DNs in order of provided members. Duplicates are skipped.
+func foundMembersDn(members []string, found map[string]string) []string {
+	var result []string
+	for _, m := range members {
+		dn, ok := found[m]
+		if !ok || slices.ContainsFunc(result, func(v string) bool { return strings.EqualFold(v, dn) }) {
+			continue
+		}
+		result = append(result, dn)
+	}
+	return result
+}
+
+// Same as AddGroupMembersContext with background context.
+func (cl *Client) AddGroupMembers(groupId string, members ...string) (int, error) {
+	return cl.AddGroupMembersContext(context.Background(), groupId, members...)
+}
+
+// Adds provided members to the group. Members can be provided by ID or DN and can be users, groups, computers
+// or any other objects. Returns number of added members. Not found members and members of the group are skipped.
+// Context cancellation stops all pending members lookups.
+func (cl *Client) AddGroupMembersContext(ctx context.Context, groupId string, members ...string) (int, error) {
+	group, err := cl.GetGroupContext(ctx, GetGroupArgs{Id: groupId, SkipMembersSearch: true})
+	if err != nil {
+		return 0, fmt.Errorf("can't get group '%s': %w", groupId, err)
+	}
+
+	found, err := cl.findMembersDn(ctx, domainBase(group.DN), members)
+	if err != nil {
+		return 0, err
+	}
+	toAdd := foundMembersDn(members, found)
+	if len(toAdd) == 0 {
+		return 0, nil
+	}
+
+	cl.logger.Debugf("Adding %d new members to group '%s'", len(toAdd), groupId)
+
+	added := 0
+	for _, dn := range toAdd {
+		ok, err := cl.addGroupMember(ctx, group.DN, dn)
+		if err != nil {
+			return added, err
+		}
+		if ok {
+			added++
+		}
+	}
+
+	return added, nil
+}
+
+// Adds member DN to group 'member' attribute. Returns false if DN already is a member of the group.
+func (cl *Client) addGroupMember(ctx context.Context, groupDn, memberDn string) (bool, error) {
+	mr := ldap.NewModifyRequest(groupDn, nil)
+	mr.Add("member", []string{memberDn})
+	err := cl.modify(ctx, mr)
+	if errors.Is(err, ErrAlreadyExists) {
+		cl.logger.Debugf("'%s' is already a member of the group '%s'", memberDn, groupDn)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Same as DeleteGroupMembersContext with background context.
+func (cl *Client) DeleteGroupMembers(groupId string, members ...string) (int, error) {
+	return cl.DeleteGroupMembersContext(context.Background(), groupId, members...)
+}
+
+// Deletes provided members from the group. Members can be provided by ID or DN and can be users, groups, computers
+// or any other objects. Returns number of deleted members. Not found members and non-members are skipped.
+// Context cancellation stops all pending members lookups.
+func (cl *Client) DeleteGroupMembersContext(ctx context.Context, groupId string, members ...string) (int, error) {
+	group, err := cl.GetGroupContext(ctx, GetGroupArgs{Id: groupId, SkipMembersSearch: true})
+	if err != nil {
+		return 0, fmt.Errorf("can't get group '%s': %w", groupId, err)
+	}
+
+	found, err := cl.findMembersDn(ctx, domainBase(group.DN), members)
+	if err != nil {
+		return 0, err
+	}
+	toDel := foundMembersDn(members, found)
+	if len(toDel) == 0 {
+		return 0, nil
+	}
+
+	cl.logger.Debugf("Deleting %d members from group '%s'", len(toDel), groupId)
+
+	deleted := 0
+	for _, dn := range toDel {
+		ok, err := cl.deleteGroupMember(ctx, group.DN, dn)
+		if err != nil {
+			return deleted, err
+		}
+		if ok {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// Deletes member DN from group 'member' attribute. Returns false if DN already isn't a member of the group.
+func (cl *Client) deleteGroupMember(ctx context.Context, groupDn, memberDn string) (bool, error) {
+	mr := ldap.NewModifyRequest(groupDn, nil)
+	mr.Delete("member", []string{memberDn})
+	err := cl.modify(ctx, mr)
+	if isNotMemberError(err) {
+		cl.logger.Debugf("'%s' already isn't a member of the group '%s'", memberDn, groupDn)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reports whether error is returned on deletion of non-member from group.
+// AD returns 'unwilling to perform' with ERROR_MEMBER_NOT_IN_GROUP (0x561) code, other servers 'no such attribute'.
+func isNotMemberError(err error) bool {
+	if ldap.IsErrorWithCode(err, ldap.LDAPResultNoSuchAttribute) {
+		return true
+	}
+	var lerr *ldap.Error
+	return errors.As(err, &lerr) &&
+		lerr.ResultCode == ldap.LDAPResultUnwillingToPerform &&
+		strings.Contains(strings.ToUpper(lerr.Err.Error()), "00000561")
+}
+
+type SyncGroupMembersArgs struct {
+	GroupId string
+	// Desired group members IDs or DNs. Members of the group not listed here are removed from the group.
+	Members []string
+	// Only reports changes without applying them.
+	DryRun bool
+	// Sync group even if some desired members aren't found. By default sync is refused in this case,
+	// cause members that can't be resolved, e.g. because of a typo, would be removed from the group.
+	AllowUnresolved bool
+}
+
+func (args SyncGroupMembersArgs) Validate() error {
+	if args.GroupId == "" {
+		return errors.New("Group ID is required")
+	}
+	if slices.Contains(args.Members, "") {
+		return errors.New("member ID or DN can't be empty")
+	}
+	return nil
+}
+
+// Group members synchronization result.
+type SyncGroupMembersReport struct {
+	// DNs of added members.
+	Added []string `json:"added"`
+	// DNs of removed members.
+	Removed []string `json:"removed"`
+	// Desired members IDs or DNs that wasn't found.
+	Unresolved []string `json:"unresolved"`
+	// Changes weren't applied because of dry-run mode.
+	DryRun bool `json:"dry_run"`
+}
+
+// Reports whether group members were or would be changed.
+func (r *SyncGroupMembersReport) HasChanges() bool {
+	return len(r.Added) > 0 || len(r.Removed) > 0
+}
+
+// Same as SyncGroupMembersContext with background context.
+func (cl *Client) SyncGroupMembers(args SyncGroupMembersArgs) (*SyncGroupMembersReport, error) {
+	return cl.SyncGroupMembersContext(context.Background(), args)
+}
+
+// Makes group members match the desired members list by adding missing and removing extra members.
+// Unresolved desired members are listed in the report. If there are any, 'ErrUnresolvedMembers' error is returned
+// and no changes are applied, unless AllowUnresolved arg is set. In dry-run mode report still lists planned changes.
+// On error returns report with changes that were applied before the error.
+func (cl *Client) SyncGroupMembersContext(ctx context.Context, args SyncGroupMembersArgs) (*SyncGroupMembersReport, error) {
+	if err := args.Validate(); err != nil {
+		return nil, fmt.Errorf("Bad request: %w", err)
+	}
+
+	group, err := cl.GetGroupContext(ctx, GetGroupArgs{Id: args.GroupId, SkipMembersSearch: true})
+	if err != nil {
+		return nil, fmt.Errorf("can't get group '%s': %w", args.GroupId, err)
+	}
+
+	currentDn, err := cl.getGroupMembersDn(ctx, group.DN)
+	if err != nil {
+		return nil, fmt.Errorf("can't get group members: %w", err)
+	}
+	found, err := cl.findMembersDn(ctx, domainBase(group.DN), args.Members)
+	if err != nil {
+		return nil, err
+	}
+	desiredDn := foundMembersDn(args.Members, found)
+
+	report := &SyncGroupMembersReport{DryRun: args.DryRun}
+	for _, m := range args.Members {
+		if _, ok := found[m]; !ok && !slices.Contains(report.Unresolved, m) {
+			report.Unresolved = append(report.Unresolved, m)
+		}
+	}
+
+	toAdd := differenceFold(desiredDn, currentDn)
+	toDel := differenceFold(currentDn, desiredDn)
+
+	var unresolvedErr error
+	if len(report.Unresolved) > 0 && !args.AllowUnresolved {
+		unresolvedErr = fmt.Errorf("%w: %s", ErrUnresolvedMembers, strings.Join(report.Unresolved, ", "))
+	}
+
+	if args.DryRun {
+		report.Added, report.Removed = toAdd, toDel
+		return report, unresolvedErr
+	}
+	if unresolvedErr != nil {
+		return report, unresolvedErr
+	}
+
+	cl.logger.Debugf("Syncing group '%s' members: %d to add, %d to remove", args.GroupId, len(toAdd), len(toDel))
+
+	for _, dn := range toAdd {
+		ok, err := cl.addGroupMember(ctx, group.DN, dn)
+		if err != nil {
+			return report, err
+		}
+		if ok {
+			report.Added = append(report.Added, dn)
+		}
+	}
+	for _, dn := range toDel {
+		ok, err := cl.deleteGroupMember(ctx, group.DN, dn)
+		if err != nil {
+			return report, err
+		}
+		if ok {
+			report.Removed = append(report.Removed, dn)
+		}
+	}
+
+	return report, nil
+}
+
+// Returns DNs from the first list that aren't present in the second list. DNs are compared case-insensitively.
+func differenceFold(a, b []string) []string {
+	var result []string
+	for _, v := range a {
+		if !containsFold(b, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -0,0 +1,124 @@
+package adc
+
+import (
+	"context"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Performs search request page by page using Simple Paged Results control.
+// Paged search state is bound to the connection, so pager holds a single connection borrowed
+// from the pool until the last page is fetched or pager is closed.
+type pager struct {
+	cl      *Client
+	req     *ldap.SearchRequest
+	control *ldap.ControlPaging
+	done    bool
+
+	pool *pool
+	conn ldap.Client
+	// Connection is held by iterator, see pool.getIter.
+	iter bool
+}
+
+// Creates new pager for provided search request with page size from client config.
+func (cl *Client) newPager(req *ldap.SearchRequest) *pager {
+	control := ldap.NewControlPaging(cl.Config.PageSize)
+	req.Controls = append(req.Controls, control)
+	return &pager{cl: cl, req: req, control: control}
+}
+
+// Creates new pager for iterator. Iterator connection is taken from pool iterators slots.
+func (cl *Client) newIterPager(req *ldap.SearchRequest) *pager {
+	p := cl.newPager(req)
+	p.iter = true
+	return p
+}
+
+// Fetches next page of entries. Returns nil if there are no more pages.
+func (p *pager) next(ctx context.Context) ([]*ldap.Entry, error) {
+	if p.done {
+		return nil, nil
+	}
+
+	if p.conn == nil {
+		pool, err := p.cl.getPool()
+		if err != nil {
+			p.done = true
+			return nil, err
+		}
+		get := pool.get
+		if p.iter {
+			get = pool.getIter
+		}
+		conn, err := get(ctx)
+		if err != nil {
+			p.done = true
+			return nil, err
+		}
+		p.pool, p.conn = pool, conn
+	}
+
+	result, err := searchConn(ctx, p.conn, p.req)
+	// Paged search state can't be moved to another connection, so only the first page is retried.
+	if err != nil && len(p.control.Cookie) == 0 && p.cl.canReconnect(ctx, err) {
+		var conn ldap.Client
+		conn, err = p.cl.reconnectConn(ctx, p.conn, err)
+		if err == nil {
+			p.conn = conn
+			p.cl.logger.Debug("Retrying operation after reconnect")
+			result, err = searchConn(ctx, p.conn, p.req)
+		}
+	}
+	if err != nil {
+		p.done = true
+		p.release()
+		return nil, err
+	}
+
+	p.done = true
+	if c, ok := ldap.FindControl(result.Controls, ldap.ControlTypePaging).(*ldap.ControlPaging); ok && len(c.Cookie) > 0 {
+		p.control.SetCookie(c.Cookie)
+		p.done = false
+	}
+	if p.done {
+		p.release()
+	}
+
+	return result.Entries, nil
+}
+
+// Abandons paged search on the server if there are pages left. Abandon request is limited by client timeout.
+func (p *pager) close() error {
+	if p.done {
+		return nil
+	}
+	p.done = true
+	if p.conn == nil {
+		return nil
+	}
+	defer p.release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.cl.Config.Timeout)
+	defer cancel()
+
+	p.control.PagingSize = 0
+	if _, err := searchConn(ctx, p.conn, p.req); err != nil {
+		// Connection state is unknown, so it isn't returned to the pool.
+		p.conn.Close()
+		return err
+	}
+	return nil
+}
+
+// Returns held connection to the pool.
+func (p *pager) release() {
+	if p.conn != nil {
+		if p.iter {
+			p.pool.putIter(p.conn)
+		} else {
+			p.pool.put(p.conn)
+		}
+		p.pool, p.conn = nil, nil
+	}
+}
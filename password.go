@@ -0,0 +1,85 @@
+package adc
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"unicode/utf16"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Encodes password to the 'unicodePwd' attribute format: quoted and UTF-16LE encoded.
+func encodePassword(password string) string {
+	encoded := utf16.Encode([]rune(`"` + password + `"`))
+	result := make([]byte, 2*len(encoded))
+	for i, v := range encoded {
+		binary.LittleEndian.PutUint16(result[2*i:], v)
+	}
+	return string(result)
+}
+
+// Returns 'ErrInsecureConnection' error if client connections aren't encrypted.
+// AD accepts password modifications only over secure connections.
+func (cl *Client) checkSecure(ctx context.Context) error {
+	return cl.withConn(ctx, func(conn ldap.Client) error {
+		if _, ok := conn.TLSConnectionState(); !ok {
+			return ErrInsecureConnection
+		}
+		return nil
+	})
+}
+
+// Same as SetUserPasswordContext with background context.
+func (cl *Client) SetUserPassword(userId, password string) error {
+	return cl.SetUserPasswordContext(context.Background(), userId, password)
+}
+
+// Sets new password for user by ID. Used for administrative password reset and requires secure connection.
+func (cl *Client) SetUserPasswordContext(ctx context.Context, userId, password string) error {
+	if password == "" {
+		return errors.New("password is required")
+	}
+	if err := cl.checkSecure(ctx); err != nil {
+		return err
+	}
+
+	user, err := cl.findUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	cl.logger.Debugf("Setting password for user '%s'", userId)
+
+	mr := ldap.NewModifyRequest(user.DN, nil)
+	mr.Replace("unicodePwd", []string{encodePassword(password)})
+	return passwordError(cl.modify(ctx, mr))
+}
+
+// Same as ChangeUserPasswordContext with background context.
+func (cl *Client) ChangeUserPassword(userId, oldPassword, newPassword string) error {
+	return cl.ChangeUserPasswordContext(context.Background(), userId, oldPassword, newPassword)
+}
+
+// Changes user password by ID. Old password must be provided and requires secure connection.
+func (cl *Client) ChangeUserPasswordContext(ctx context.Context, userId, oldPassword, newPassword string) error {
+	if oldPassword == "" || newPassword == "" {
+		return errors.New("old and new passwords are required")
+	}
+	if err := cl.checkSecure(ctx); err != nil {
+		return err
+	}
+
+	user, err := cl.findUser(ctx, userId)
+	if err != nil {
+		return err
+	}
+
+	cl.logger.Debugf("Changing password for user '%s'", userId)
+
+	// Deleting of the old value and adding of the new one in single request is treated by AD as password change.
+	mr := ldap.NewModifyRequest(user.DN, nil)
+	mr.Delete("unicodePwd", []string{encodePassword(oldPassword)})
+	mr.Add("unicodePwd", []string{encodePassword(newPassword)})
+	return passwordError(cl.modify(ctx, mr))
+}
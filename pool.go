@@ -0,0 +1,240 @@
+package adc
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Bounded pool of bound connections. Connections are borrowed for a single operation
+// (or for the whole paged search) and returned back to the pool.
+//
+// Iterators hold a connection while caller processes entries, and fetch user groups or group members
+// using another one. So iterators can't take all slots, otherwise concurrent iterators could wait
+// for each other forever.
+type pool struct {
+	dial        func(ctx context.Context) (ldap.Client, error)
+	idleTimeout time.Duration
+	// Limits number of open connections: a slot is taken on borrow and released on return.
+	slots chan struct{}
+	// Limits number of slots held by iterators. Iterator takes both iterator slot and connection slot.
+	iterSlots chan struct{}
+
+	mu     sync.Mutex
+	idle   []idleConn
+	closed bool
+	// Stops idle connections janitor.
+	done chan struct{}
+}
+
+type idleConn struct {
+	conn  ldap.Client
+	since time.Time
+}
+
+// Min pool size: iterator holds one connection and needs another one for nested lookups.
+const minPoolSize = 2
+
+// Creates new pool with provided max size. Iterators can hold all connections but one.
+// Connections idle longer than idle timeout are closed.
+func newPool(size int, idleTimeout time.Duration, dial func(ctx context.Context) (ldap.Client, error)) *pool {
+	size = max(size, minPoolSize)
+	p := &pool{
+		dial:        dial,
+		idleTimeout: idleTimeout,
+		slots:       make(chan struct{}, size),
+		iterSlots:   make(chan struct{}, size-1),
+		done:        make(chan struct{}),
+	}
+	if idleTimeout > 0 {
+		go p.janitor()
+	}
+	return p
+}
+
+// Closes expired idle connections until pool is closed, so they aren't kept open on idle client.
+func (p *pool) janitor() {
+	ticker := time.NewTicker(max(p.idleTimeout/2, time.Millisecond))
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			p.mu.Lock()
+			p.closeExpired()
+			p.mu.Unlock()
+		case <-p.done:
+			return
+		}
+	}
+}
+
+// Closes idle connections expired by idle timeout. Must be called with pool lock held.
+func (p *pool) closeExpired() {
+	// Idle connections are taken from the end, so the oldest ones are at the beginning.
+	for len(p.idle) > 0 && p.expired(p.idle[0]) {
+		p.idle[0].conn.Close()
+		p.idle = p.idle[1:]
+	}
+}
+
+// Borrows connection from the pool. Dials new connection if there are no idle connections.
+// Waits for a returned connection if pool max size is reached.
+func (p *pool) get(ctx context.Context) (ldap.Client, error) {
+	return p.take(ctx, p.slots)
+}
+
+// Returns borrowed connection to the pool. Closed connections are dropped.
+func (p *pool) put(conn ldap.Client) {
+	p.give(conn, p.slots)
+}
+
+// Borrows connection for iterator. Same as get, but also takes iterator slot,
+// so at least one connection is left for other operations.
+func (p *pool) getIter(ctx context.Context) (ldap.Client, error) {
+	select {
+	case p.iterSlots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	conn, err := p.take(ctx, p.slots)
+	if err != nil {
+		<-p.iterSlots
+		return nil, err
+	}
+	return conn, nil
+}
+
+// Returns connection borrowed with getIter to the pool.
+func (p *pool) putIter(conn ldap.Client) {
+	p.give(conn, p.slots)
+	<-p.iterSlots
+}
+
+func (p *pool) take(ctx context.Context, slots chan struct{}) (ldap.Client, error) {
+	select {
+	case slots <- struct{}{}:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-slots
+		return nil, ErrNotConnected
+	}
+	for len(p.idle) > 0 {
+		c := p.idle[len(p.idle)-1]
+		p.idle = p.idle[:len(p.idle)-1]
+		if c.conn.IsClosing() || p.expired(c) {
+			c.conn.Close()
+			continue
+		}
+		p.mu.Unlock()
+		return c.conn, nil
+	}
+	p.mu.Unlock()
+
+	conn, err := p.dial(ctx)
+	if err != nil {
+		<-slots
+		return nil, err
+	}
+	return conn, nil
+}
+
+func (p *pool) give(conn ldap.Client, slots chan struct{}) {
+	p.mu.Lock()
+	if p.closed || conn.IsClosing() {
+		conn.Close()
+	} else {
+		p.idle = append(p.idle, idleConn{conn: conn, since: time.Now()})
+	}
+	p.closeExpired()
+	p.mu.Unlock()
+	<-slots
+}
+
+// Adds connection to the pool as idle. Used for the initial connection.
+func (p *pool) add(conn ldap.Client) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.idle = append(p.idle, idleConn{conn: conn, since: time.Now()})
+}
+
+func (p *pool) expired(c idleConn) bool {
+	return p.idleTimeout > 0 && time.Since(c.since) > p.idleTimeout
+}
+
+// Closes idle connections. Borrowed connections are closed on return.
+func (p *pool) close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	var err error
+	for _, c := range p.idle {
+		if cerr := c.conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+	}
+	p.idle = nil
+	return err
+}
+
+// Returns client connections pool or 'ErrNotConnected' error if client isn't connected.
+func (cl *Client) getPool() (*pool, error) {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+	if cl.pool == nil {
+		return nil, ErrNotConnected
+	}
+	return cl.pool, nil
+}
+
+// Runs provided operation with a connection borrowed from the pool.
+// Connection is restored if operation fails with network error and automatic reconnection is enabled,
+// but operation isn't retried. Use for non-idempotent operations.
+func (cl *Client) withConn(ctx context.Context, op func(conn ldap.Client) error) error {
+	return cl.runConn(ctx, false, op)
+}
+
+// Same as withConn, but retries operation once after reconnection. Use for idempotent operations.
+func (cl *Client) withConnRetry(ctx context.Context, op func(conn ldap.Client) error) error {
+	return cl.runConn(ctx, true, op)
+}
+
+func (cl *Client) runConn(ctx context.Context, retry bool, op func(conn ldap.Client) error) error {
+	p, err := cl.getPool()
+	if err != nil {
+		return err
+	}
+	conn, err := p.get(ctx)
+	if err != nil {
+		return err
+	}
+	// Lost connection is closed, so it's dropped by the pool on return.
+	defer func() { p.put(conn) }()
+
+	err = op(conn)
+	if err == nil || !cl.canReconnect(ctx, err) {
+		return err
+	}
+
+	newConn, rerr := cl.reconnectConn(ctx, conn, err)
+	if rerr != nil {
+		return rerr
+	}
+	conn = newConn
+
+	if !retry {
+		return err
+	}
+	cl.logger.Debug("Retrying operation after reconnect")
+	return op(conn)
+}
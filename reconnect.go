@@ -0,0 +1,117 @@
+package adc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Automatic reconnection settings. Operations failed with network error redial the server
+// with exponential backoff, rebind and retry once if operation is idempotent (search).
+// Write operations aren't retried, cause they may have been applied by the server.
+type ReconnectConfig struct {
+	// Max number of dial attempts. Defaults to 3.
+	MaxAttempts int `json:"max_attempts"`
+	// Delay before the second attempt. Doubled for each next attempt. Defaults to 500 milliseconds.
+	MinBackoff time.Duration `json:"min_backoff"`
+	// Max delay between attempts. Defaults to 10 seconds.
+	MaxBackoff time.Duration `json:"max_backoff"`
+}
+
+func (cfg *ReconnectConfig) maxAttempts() int {
+	if cfg.MaxAttempts > 0 {
+		return cfg.MaxAttempts
+	}
+	return 3
+}
+
+// Returns delay before provided attempt with random jitter in range [delay/2, delay].
+func (cfg *ReconnectConfig) backoff(attempt int) time.Duration {
+	minBackoff, maxBackoff := cfg.MinBackoff, cfg.MaxBackoff
+	if minBackoff <= 0 {
+		minBackoff = 500 * time.Millisecond
+	}
+	if maxBackoff <= 0 {
+		maxBackoff = 10 * time.Second
+	}
+
+	delay := minBackoff
+	for i := 2; i < attempt && delay < maxBackoff; i++ {
+		delay *= 2
+	}
+	delay = min(delay, maxBackoff)
+
+	half := delay / 2
+	return half + rand.N(delay-half+1)
+}
+
+// Reports whether error is caused by lost or failed connection to the server.
+// Note that context.DeadlineExceeded implements net.Error, so context must be checked separately.
+func isNetworkError(err error) bool {
+	if ldap.IsErrorWithCode(err, ldap.ErrorNetwork) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// Reports whether failed operation should be followed by reconnection. Operation failed because of
+// its context deadline or cancellation isn't reconnected, cause the connection is still healthy.
+func (cl *Client) canReconnect(ctx context.Context, err error) bool {
+	return cl.Config.Reconnect != nil && ctx.Err() == nil && isNetworkError(err)
+}
+
+// Connects to AD server and binds with client bind account.
+// Retries on network errors with backoff if automatic reconnection is enabled.
+func (cl *Client) dialBound(ctx context.Context) (ldap.Client, error) {
+	rc := cl.Config.Reconnect
+	if rc == nil {
+		return cl.connectBound(ctx)
+	}
+
+	attempts := rc.maxAttempts()
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		if attempt > 1 {
+			delay := rc.backoff(attempt)
+			cl.logger.Debugf("Reconnecting in %s. Attempt: %d/%d", delay, attempt, attempts)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			}
+		}
+
+		var conn ldap.Client
+		conn, err = cl.connectBound(ctx)
+		if err == nil {
+			return conn, nil
+		}
+		if !isNetworkError(err) || ctx.Err() != nil {
+			return nil, err
+		}
+		cl.logger.Debugf("Connection attempt %d/%d failed: %s", attempt, attempts, err.Error())
+	}
+	return nil, fmt.Errorf("failed after '%d' attempts: %w", attempts, err)
+}
+
+// Closes connection lost with provided error and dials a new one.
+func (cl *Client) reconnectConn(ctx context.Context, conn ldap.Client, cause error) (ldap.Client, error) {
+	cl.logger.Debugf("Connection lost: %s", cause.Error())
+	conn.Close()
+
+	newConn, err := cl.dialBound(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("%w; reconnect failed: %w", cause, err)
+	}
+	cl.logger.Debugf("Reconnected to '%s'", cl.ServerURL())
+	return newConn, nil
+}
@@ -8,3 +8,6 @@ samba-tool group addmembers testgroup1 testuser1
 
 samba-tool group add testgroup2
 samba-tool group addmembers testgroup2 testuser2
+
+samba-tool group add testgroup3
+samba-tool group addmembers testgroup3 testgroup1
@@ -0,0 +1,71 @@
+package adc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+)
+
+// Order in which servers URLs are tried on connect.
+type URLStrategy string
+
+const (
+	// Servers are tried in configured order. Default.
+	URLStrategyInOrder URLStrategy = "in_order"
+	// Servers are tried in random order.
+	URLStrategyRandom URLStrategy = "random"
+	// Each connect starts from the next server.
+	URLStrategyRoundRobin URLStrategy = "round_robin"
+)
+
+// Returns configured servers URLs ordered by configured strategy.
+// Discovered domain controllers are used instead if discovery is configured. Configured URLs are
+// used as fallback if discovery fails.
+func (cl *Client) serverURLs(ctx context.Context) ([]string, error) {
+	if cl.Config.Discovery != nil {
+		urls, err := cl.discoverURLs(ctx)
+		if err == nil {
+			return urls, nil
+		}
+		if cl.Config.URL == "" && len(cl.Config.URLs) == 0 {
+			return nil, err
+		}
+		cl.logger.Debugf("Using configured URLs: %s", err.Error())
+	}
+
+	var urls []string
+	for _, u := range append([]string{cl.Config.URL}, cl.Config.URLs...) {
+		if u != "" && !slices.Contains(urls, u) {
+			urls = append(urls, u)
+		}
+	}
+	if len(urls) == 0 {
+		return nil, errors.New("server URL is required")
+	}
+	return cl.orderURLs(urls)
+}
+
+// Orders provided URLs by configured strategy.
+func (cl *Client) orderURLs(urls []string) ([]string, error) {
+	switch cl.Config.URLStrategy {
+	case "", URLStrategyInOrder:
+		return urls, nil
+	case URLStrategyRandom:
+		rand.Shuffle(len(urls), func(i, j int) { urls[i], urls[j] = urls[j], urls[i] })
+		return urls, nil
+	case URLStrategyRoundRobin:
+		start := int((cl.nextURL.Add(1) - 1) % uint32(len(urls)))
+		return append(urls[start:], urls[:start]...), nil
+	}
+	return nil, fmt.Errorf("unsupported URL strategy '%s'", cl.Config.URLStrategy)
+}
+
+// Returns URL of the server client is connected to. Returns empty string if client isn't connected.
+// Pooled connections may be connected to different servers, URL of the last connection is returned.
+func (cl *Client) ServerURL() string {
+	cl.mu.RLock()
+	defer cl.mu.RUnlock()
+	return cl.url
+}
@@ -0,0 +1,121 @@
+package adctests
+
+import (
+	"testing"
+
+	"github.com/dlampsi/adc"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_UserAccountControl_Has(t *testing.T) {
+	uac := adc.UACNormalAccount | adc.UACAccountDisable
+	require.True(t, uac.Has(adc.UACNormalAccount))
+	require.True(t, uac.Has(adc.UACAccountDisable))
+	require.True(t, uac.Has(adc.UACNormalAccount|adc.UACAccountDisable))
+	require.False(t, uac.Has(adc.UACLockout))
+	require.False(t, uac.Has(adc.UACAccountDisable|adc.UACLockout))
+}
+
+func Test_User_AccountState(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		u := &adc.User{}
+		require.False(t, u.IsDisabled())
+		require.False(t, u.IsLockedOut())
+		require.False(t, u.PasswordNeverExpires())
+		require.False(t, u.PasswordExpired())
+		require.False(t, u.SmartcardRequired())
+	})
+	t.Run("AllSet", func(t *testing.T) {
+		u := &adc.User{
+			AccountControl: adc.UACAccountDisable | adc.UACLockout | adc.UACDontExpirePassword |
+				adc.UACPasswordExpired | adc.UACSmartcardRequired,
+		}
+		require.True(t, u.IsDisabled())
+		require.True(t, u.IsLockedOut())
+		require.True(t, u.PasswordNeverExpires())
+		require.True(t, u.PasswordExpired())
+		require.True(t, u.SmartcardRequired())
+	})
+}
+
+func Test_Client_GetUser_AccountControl(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	t.Run("Ok", func(t *testing.T) {
+		user, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1", SkipGroupsSearch: true})
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		require.True(t, user.AccountControl.Has(adc.UACNormalAccount))
+		require.False(t, user.IsDisabled())
+		require.False(t, user.IsLockedOut())
+		require.NotContains(t, user.Attributes, "userAccountControl", "Implicitly requested attributes should not be returned")
+	})
+	t.Run("OkWithRequestedAttributes", func(t *testing.T) {
+		user, err := cl.GetUser(adc.GetUserArgs{
+			Id:               "testuser1",
+			Attributes:       []string{"sAMAccountName", "userAccountControl"},
+			SkipGroupsSearch: true,
+		})
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		require.True(t, user.AccountControl.Has(adc.UACNormalAccount))
+		require.Contains(t, user.Attributes, "userAccountControl")
+		require.NotContains(t, user.Attributes, "msDS-User-Account-Control-Computed")
+	})
+}
+
+func Test_Client_DisableEnableUser(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	const userId = "testuser2"
+
+	getUser := func() *adc.User {
+		user, err := cl.GetUser(adc.GetUserArgs{Id: userId, SkipGroupsSearch: true})
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		return user
+	}
+
+	t.Run("NonExists", func(t *testing.T) {
+		require.Error(t, cl.DisableUser("nonexists"))
+		require.Error(t, cl.EnableUser("nonexists"))
+	})
+	t.Run("Ok", func(t *testing.T) {
+		before := getUser()
+		require.False(t, before.IsDisabled())
+
+		require.NoError(t, cl.DisableUser(userId))
+		disabled := getUser()
+		require.True(t, disabled.IsDisabled())
+		require.Equal(t, before.AccountControl|adc.UACAccountDisable, disabled.AccountControl, "Other flags should be kept")
+
+		require.NoError(t, cl.DisableUser(userId), "Disabling of disabled user should be no-op")
+
+		require.NoError(t, cl.EnableUser(userId))
+		enabled := getUser()
+		require.False(t, enabled.IsDisabled())
+		require.Equal(t, before.AccountControl, enabled.AccountControl)
+	})
+}
+
+func Test_Client_UnlockUser(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	t.Run("NonExists", func(t *testing.T) {
+		require.Error(t, cl.UnlockUser("nonexists"))
+	})
+	t.Run("Ok", func(t *testing.T) {
+		require.NoError(t, cl.UnlockUser("testuser1"))
+
+		user, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1", SkipGroupsSearch: true})
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		require.False(t, user.IsLockedOut())
+	})
+}
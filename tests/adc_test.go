@@ -45,6 +45,115 @@ func Test_Client_Connect(t *testing.T) {
 		require.NoError(t, tClient.Connect())
 	})
 }
+
+func Test_Client_Failover(t *testing.T) {
+	const (
+		badURL  = "ldaps://127.0.0.1:1"
+		goodURL = "ldaps://127.0.0.1:636"
+	)
+
+	t.Run("Ok", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.URL = ""
+		cfg.URLs = []string{badURL, goodURL}
+		cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+		require.Equal(t, goodURL, cl.ServerURL())
+		require.NoError(t, cl.CheckAuthByDN(cfg.Bind.DN, cfg.Bind.Password), "Auth check should fail over too")
+	})
+	t.Run("AllFailed", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.URL = badURL
+		cfg.URLs = []string{"ldaps://127.0.0.1:2"}
+		cl := adc.New(&cfg)
+		err := cl.Connect()
+		require.ErrorContains(t, err, badURL)
+		require.ErrorContains(t, err, "ldaps://127.0.0.1:2")
+		require.Empty(t, cl.ServerURL())
+	})
+	t.Run("RoundRobin", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.URLs = []string{"ldaps://localhost:636"}
+		cfg.URLStrategy = adc.URLStrategyRoundRobin
+		cl := adc.New(&cfg)
+
+		var used []string
+		for i := 0; i < 3; i++ {
+			require.NoError(t, cl.Connect())
+			used = append(used, cl.ServerURL())
+			require.NoError(t, cl.Disconnect())
+		}
+		require.Equal(t, []string{cfg.URL, "ldaps://localhost:636", cfg.URL}, used)
+	})
+	t.Run("Random", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.URLs = []string{"ldaps://localhost:636"}
+		cfg.URLStrategy = adc.URLStrategyRandom
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		require.Contains(t, []string{cfg.URL, "ldaps://localhost:636"}, cl.ServerURL())
+		require.NoError(t, cl.Disconnect())
+	})
+	t.Run("BadStrategy", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.URLStrategy = "bad"
+		cl := adc.New(&cfg)
+		require.ErrorContains(t, cl.Connect(), "unsupported URL strategy")
+	})
+}
+
+func Test_Client_StartTLS(t *testing.T) {
+	t.Run("Ok", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.URL = "ldap://127.0.0.1:389"
+		cfg.StartTLS = true
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		// Password can be set over secure connection only.
+		user, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1", SkipGroupsSearch: true})
+		require.NoError(t, err)
+		require.NoError(t, cl.CheckAuthByDN(cfg.Bind.DN, cfg.Bind.Password))
+		require.NotErrorIs(t, cl.SetUserPassword(user.Id, "Rand0m-Passw0rd"), adc.ErrInsecureConnection)
+	})
+	t.Run("WithLdapsUrl", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.StartTLS = true
+		cl := adc.New(&cfg)
+		require.Error(t, cl.Connect(), "StartTLS over LDAPS should fail")
+		require.Error(t, cl.CheckAuthByDN(cfg.Bind.DN, cfg.Bind.Password))
+	})
+	t.Run("FailClosed", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.URL = "ldap://127.0.0.1:389"
+		cfg.StartTLS = true
+		cfg.InsecureTLS = false
+		cfg.Bind.Password = "bad_password"
+		cl := adc.New(&cfg)
+		// Server certificate is self-signed, so the upgrade fails and bind isn't performed.
+		err := cl.Connect()
+		require.ErrorContains(t, err, "StartTLS failed", "Credentials shouldn't be sent after failed upgrade")
+	})
+}
+
+func Test_Client_ConnectContext(t *testing.T) {
+	t.Run("Ok", func(t *testing.T) {
+		cfg := getClientConfig()
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.ConnectContext(context.Background()))
+		require.NoError(t, cl.Disconnect())
+	})
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		cfg := getClientConfig()
+		cl := adc.New(&cfg)
+		require.ErrorIs(t, cl.ConnectContext(ctx), context.Canceled)
+	})
+}
 func Test_Client_Reconnect(t *testing.T) {
 	ctx := context.TODO()
 
@@ -115,6 +224,12 @@ func Test_Client_CheckAuthByDN(t *testing.T) {
 	t.Run("Ok", func(t *testing.T) {
 		require.NoError(t, tClient.CheckAuthByDN(tClient.Config.Bind.DN, tClient.Config.Bind.Password))
 	})
+	t.Run("WithContextCancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := tClient.CheckAuthByDNContext(ctx, tClient.Config.Bind.DN, tClient.Config.Bind.Password)
+		require.ErrorIs(t, err, context.Canceled)
+	})
 	t.Run("WithConnectErr", func(t *testing.T) {
 		cfg := getClientConfig()
 		cl := adc.New(&cfg)
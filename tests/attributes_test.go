@@ -0,0 +1,66 @@
+package adctests
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/dlampsi/adc"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_DecodeGUID(t *testing.T) {
+	t.Run("Ok", func(t *testing.T) {
+		raw := []byte{0x67, 0x45, 0x23, 0x01, 0xab, 0x89, 0xef, 0xcd, 0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}
+		guid, err := adc.DecodeGUID(raw)
+		require.NoError(t, err)
+		require.Equal(t, "01234567-89ab-cdef-0123-456789abcdef", guid)
+	})
+	t.Run("BadLength", func(t *testing.T) {
+		_, err := adc.DecodeGUID([]byte{0x01, 0x02})
+		require.Error(t, err)
+	})
+}
+
+func Test_DecodeSID(t *testing.T) {
+	t.Run("Ok", func(t *testing.T) {
+		raw := []byte{0x01, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00, 0x20, 0x02, 0x00, 0x00}
+		sid, err := adc.DecodeSID(raw)
+		require.NoError(t, err)
+		require.Equal(t, "S-1-5-32-544", sid)
+	})
+	t.Run("OkDomain", func(t *testing.T) {
+		raw := []byte{
+			0x01, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05,
+			0x15, 0x00, 0x00, 0x00,
+			0xdc, 0xf4, 0xdc, 0x3b,
+			0x83, 0x3d, 0x2b, 0x46,
+			0x82, 0x8b, 0xa6, 0x28,
+			0x00, 0x02, 0x00, 0x00,
+		}
+		sid, err := adc.DecodeSID(raw)
+		require.NoError(t, err)
+		require.Equal(t, "S-1-5-21-1004336348-1177238915-682003330-512", sid)
+	})
+	t.Run("BadLength", func(t *testing.T) {
+		_, err := adc.DecodeSID([]byte{0x01, 0x02, 0x00})
+		require.Error(t, err)
+	})
+	t.Run("BadSubAuthoritiesCount", func(t *testing.T) {
+		_, err := adc.DecodeSID([]byte{0x01, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x20, 0x00, 0x00, 0x00})
+		require.Error(t, err)
+	})
+}
+
+func Test_FileTimeToTime(t *testing.T) {
+	t.Run("Ok", func(t *testing.T) {
+		require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), adc.FileTimeToTime(133485408000000000))
+	})
+	t.Run("OkWithNanoseconds", func(t *testing.T) {
+		require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 500, time.UTC), adc.FileTimeToTime(133485408000000005))
+	})
+	t.Run("Never", func(t *testing.T) {
+		require.True(t, adc.FileTimeToTime(0).IsZero())
+		require.True(t, adc.FileTimeToTime(math.MaxInt64).IsZero())
+	})
+}
@@ -0,0 +1,251 @@
+package adctests
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dlampsi/adc"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_CheckAuthByDN_AuthError(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	requireReason := func(t *testing.T, err error, reason adc.AuthReason) {
+		var authErr *adc.AuthError
+		require.ErrorAs(t, err, &authErr)
+		require.Equal(t, reason, authErr.Reason)
+	}
+
+	t.Run("InvalidCredentials", func(t *testing.T) {
+		err := cl.CheckAuthByDN(cl.Config.Bind.DN, "bad_password")
+		requireReason(t, err, adc.AuthReasonInvalidCredentials)
+	})
+
+	req := adc.CreateUserArgs{
+		Id:       "userForAuth" + time.Now().Format("20060102150405"),
+		Password: "Initial-Passw0rd",
+	}
+	require.NoError(t, cl.CreateUser(req))
+	defer func() { require.NoError(t, cl.DeleteUser(req.Id)) }()
+
+	user, err := cl.GetUser(adc.GetUserArgs{Id: req.Id, SkipGroupsSearch: true})
+	require.NoError(t, err)
+
+	t.Run("AccountDisabled", func(t *testing.T) {
+		require.NoError(t, cl.DisableUser(req.Id))
+		err := cl.CheckAuthByDN(user.DN, req.Password)
+		requireReason(t, err, adc.AuthReasonAccountDisabled)
+	})
+	t.Run("Ok", func(t *testing.T) {
+		require.NoError(t, cl.EnableUser(req.Id))
+		require.NoError(t, cl.CheckAuthByDN(user.DN, req.Password))
+	})
+}
+
+func Test_AuthError(t *testing.T) {
+	t.Run("WithoutErr", func(t *testing.T) {
+		err := &adc.AuthError{Reason: adc.AuthReasonAccountLocked}
+		require.Equal(t, "authentication failed (account_locked)", err.Error())
+		require.Nil(t, errors.Unwrap(err))
+	})
+	t.Run("WithErr", func(t *testing.T) {
+		err := &adc.AuthError{Reason: adc.AuthReasonInvalidCredentials, Err: errors.New("bind failed")}
+		require.Equal(t, "authentication failed (invalid_credentials): bind failed", err.Error())
+	})
+}
+
+func Test_Client_Authenticate(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	req := adc.CreateUserArgs{
+		Id:       "userForLogin" + time.Now().Format("20060102150405"),
+		Password: "Initial-Passw0rd",
+	}
+	req.Attributes = map[string][]string{
+		"userPrincipalName": {req.Id + "@adc.dev"},
+		"mail":              {req.Id + "@company.com"},
+	}
+	require.NoError(t, cl.CreateUser(req))
+	defer func() { require.NoError(t, cl.DeleteUser(req.Id)) }()
+	require.NoError(t, cl.EnableUser(req.Id))
+
+	cnt, err := cl.AddGroupMembers("testgroup1", req.Id)
+	require.NoError(t, err)
+	require.Equal(t, 1, cnt)
+	defer func() {
+		_, err := cl.DeleteGroupMembers("testgroup1", req.Id)
+		require.NoError(t, err)
+	}()
+
+	t.Run("EmptyPassword", func(t *testing.T) {
+		user, err := cl.Authenticate(context.Background(), req.Id, "")
+		var authErr *adc.AuthError
+		require.ErrorAs(t, err, &authErr)
+		require.Equal(t, adc.AuthReasonInvalidCredentials, authErr.Reason)
+		require.Nil(t, user)
+	})
+	t.Run("WrongPassword", func(t *testing.T) {
+		user, err := cl.Authenticate(context.Background(), req.Id, "bad_password")
+		var authErr *adc.AuthError
+		require.ErrorAs(t, err, &authErr)
+		require.Equal(t, adc.AuthReasonInvalidCredentials, authErr.Reason)
+		require.Nil(t, user)
+	})
+	t.Run("NonExists", func(t *testing.T) {
+		user, err := cl.Authenticate(context.Background(), "nonexists", req.Password)
+		var authErr *adc.AuthError
+		require.ErrorAs(t, err, &authErr)
+		require.Equal(t, adc.AuthReasonUserNotFound, authErr.Reason)
+		require.ErrorIs(t, err, adc.ErrNotFound)
+		require.Nil(t, user)
+	})
+	t.Run("FilterInjection", func(t *testing.T) {
+		_, err := cl.Authenticate(context.Background(), "*", req.Password)
+		require.ErrorIs(t, err, adc.ErrNotFound, "Login should be escaped in search filter")
+	})
+	t.Run("WithContextCancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := cl.Authenticate(ctx, req.Id, req.Password)
+		require.ErrorIs(t, err, context.Canceled)
+	})
+	for name, login := range map[string]string{
+		"BySAMAccountName":    req.Id,
+		"ByUserPrincipalName": req.Attributes["userPrincipalName"][0],
+		"ByMail":              req.Attributes["mail"][0],
+	} {
+		t.Run(name, func(t *testing.T) {
+			user, err := cl.Authenticate(context.Background(), login, req.Password)
+			require.NoError(t, err)
+			require.NotNil(t, user)
+			require.Equal(t, req.Id, user.Id)
+			require.True(t, user.IsGroupMember("testgroup1"), "User groups should be returned")
+		})
+	}
+}
+
+func Test_AuthPolicy_Evaluate(t *testing.T) {
+	user := &adc.User{
+		Groups: []adc.UserGroup{
+			{Id: "staff", DN: "CN=staff,DC=company,DC=com"},
+			{Id: "admins", DN: "CN=admins,DC=company,DC=com"},
+			{Id: "all", DN: "CN=all,DC=company,DC=com", Inherited: true},
+		},
+	}
+
+	for name, tc := range map[string]struct {
+		policy   adc.AuthPolicy
+		expected adc.AuthDecision
+	}{
+		"Empty": {
+			policy:   adc.AuthPolicy{},
+			expected: adc.AuthDecision{Allowed: true, Rule: adc.AuthRuleNoRequiredGroups},
+		},
+		"AnyOf": {
+			policy:   adc.AuthPolicy{RequiredGroups: []string{"other", "admins"}},
+			expected: adc.AuthDecision{Allowed: true, Rule: adc.AuthRuleRequiredGroup, Group: "admins"},
+		},
+		"AnyOfByDn": {
+			policy:   adc.AuthPolicy{RequiredGroups: []string{"cn=staff,dc=company,dc=com"}},
+			expected: adc.AuthDecision{Allowed: true, Rule: adc.AuthRuleRequiredGroup, Group: "cn=staff,dc=company,dc=com"},
+		},
+		"AnyOfMissing": {
+			policy:   adc.AuthPolicy{RequiredGroups: []string{"other"}},
+			expected: adc.AuthDecision{Allowed: false, Rule: adc.AuthRuleMissingRequiredGroup},
+		},
+		"AllOf": {
+			policy:   adc.AuthPolicy{RequiredGroups: []string{"staff", "admins"}, RequireAllGroups: true},
+			expected: adc.AuthDecision{Allowed: true, Rule: adc.AuthRuleAllRequiredGroups},
+		},
+		"AllOfMissing": {
+			policy:   adc.AuthPolicy{RequiredGroups: []string{"staff", "other"}, RequireAllGroups: true},
+			expected: adc.AuthDecision{Allowed: false, Rule: adc.AuthRuleMissingRequiredGroup, Group: "other"},
+		},
+		"Denied": {
+			policy:   adc.AuthPolicy{RequiredGroups: []string{"staff"}, DeniedGroups: []string{"admins"}},
+			expected: adc.AuthDecision{Allowed: false, Rule: adc.AuthRuleDeniedGroup, Group: "admins"},
+		},
+		"NestedIgnored": {
+			policy:   adc.AuthPolicy{RequiredGroups: []string{"all"}},
+			expected: adc.AuthDecision{Allowed: false, Rule: adc.AuthRuleMissingRequiredGroup},
+		},
+		"Nested": {
+			policy:   adc.AuthPolicy{RequiredGroups: []string{"all"}, NestedGroups: true},
+			expected: adc.AuthDecision{Allowed: true, Rule: adc.AuthRuleRequiredGroup, Group: "all"},
+		},
+		"NestedDenied": {
+			policy:   adc.AuthPolicy{DeniedGroups: []string{"all"}, NestedGroups: true},
+			expected: adc.AuthDecision{Allowed: false, Rule: adc.AuthRuleDeniedGroup, Group: "all"},
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.expected, tc.policy.Evaluate(user))
+		})
+	}
+}
+
+func Test_Client_AuthenticateWithPolicy(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	req := adc.CreateUserArgs{
+		Id:       "userForPolicy" + time.Now().Format("20060102150405"),
+		Password: "Initial-Passw0rd",
+	}
+	require.NoError(t, cl.CreateUser(req))
+	defer func() { require.NoError(t, cl.DeleteUser(req.Id)) }()
+	require.NoError(t, cl.EnableUser(req.Id))
+
+	// testgroup1 is a member of testgroup3.
+	cnt, err := cl.AddGroupMembers("testgroup1", req.Id)
+	require.NoError(t, err)
+	require.Equal(t, 1, cnt)
+	defer func() {
+		_, err := cl.DeleteGroupMembers("testgroup1", req.Id)
+		require.NoError(t, err)
+	}()
+
+	t.Run("WrongPassword", func(t *testing.T) {
+		result, err := cl.AuthenticateWithPolicy(context.Background(), req.Id, "bad_password", adc.AuthPolicy{})
+		var authErr *adc.AuthError
+		require.ErrorAs(t, err, &authErr)
+		require.Equal(t, adc.AuthReasonInvalidCredentials, authErr.Reason)
+		require.Nil(t, result)
+	})
+	t.Run("Allowed", func(t *testing.T) {
+		policy := adc.AuthPolicy{RequiredGroups: []string{"testgroup1"}}
+		result, err := cl.AuthenticateWithPolicy(context.Background(), req.Id, req.Password, policy)
+		require.NoError(t, err)
+		require.Equal(t, req.Id, result.User.Id)
+		require.Equal(t, adc.AuthRuleRequiredGroup, result.Decision.Rule)
+		require.Equal(t, "testgroup1", result.Decision.Group)
+	})
+	t.Run("Denied", func(t *testing.T) {
+		policy := adc.AuthPolicy{DeniedGroups: []string{"testgroup1"}}
+		result, err := cl.AuthenticateWithPolicy(context.Background(), req.Id, req.Password, policy)
+		var authErr *adc.AuthError
+		require.ErrorAs(t, err, &authErr)
+		require.Equal(t, adc.AuthReasonNotAuthorized, authErr.Reason)
+		require.NotNil(t, authErr.Decision)
+		require.Equal(t, adc.AuthRuleDeniedGroup, authErr.Decision.Rule)
+		require.Nil(t, result)
+	})
+	t.Run("NestedRequired", func(t *testing.T) {
+		policy := adc.AuthPolicy{RequiredGroups: []string{"testgroup3"}}
+		_, err := cl.AuthenticateWithPolicy(context.Background(), req.Id, req.Password, policy)
+		require.Error(t, err, "Nested group shouldn't be evaluated by default")
+
+		policy.NestedGroups = true
+		result, err := cl.AuthenticateWithPolicy(context.Background(), req.Id, req.Password, policy)
+		require.NoError(t, err)
+		require.Equal(t, "testgroup3", result.Decision.Group)
+	})
+}
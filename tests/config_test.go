@@ -1,6 +1,7 @@
 package adctests
 
 import (
+	"encoding/json"
 	"testing"
 	"time"
 
@@ -65,29 +66,45 @@ func Test_Config(t *testing.T) {
 
 	t.Run("CustomConfigAll", func(t *testing.T) {
 		cfg := &adc.Config{
-			URL:         "ldaps://fakeurl:636",
-			InsecureTLS: true,
-			Timeout:     5 * time.Second,
+			URL:              "ldaps://fakeurl:636",
+			URLs:             []string{"ldaps://fakeurl2:636"},
+			URLStrategy:      adc.URLStrategyRoundRobin,
+			Discovery:        &adc.DiscoveryConfig{Domain: "company.com", Site: "hq"},
+			InsecureTLS:      true,
+			StartTLS:         true,
+			TLS:              &adc.TLSConfig{CAFile: "/etc/ssl/ca.pem", MinVersion: "1.2"},
+			Timeout:          5 * time.Second,
+			PageSize:         100,
+			PoolSize:         5,
+			PoolIdleTimeout:  time.Minute,
+			Reconnect:        &adc.ReconnectConfig{MaxAttempts: 5},
+			BinaryAttributes: []string{"objectGUID"},
 			Bind: &adc.BindAccount{
 				DN:       "some",
 				Password: "fake",
 			},
 			SearchBase: "OU=some",
 			Users: &adc.UsersConfigs{
-				IdAttribute:      "custom-users-id-attr",
-				Attributes:       []string{"dummy-user-attr"},
-				SearchBase:       "OU=custom-users",
-				FilterById:       "customFilterById",
-				FilterByDn:       "customFilterByDn",
-				FilterGroupsByDn: "customFilterGroupsByDn",
+				IdAttribute:            "custom-users-id-attr",
+				Attributes:             []string{"dummy-user-attr"},
+				SearchBase:             "OU=custom-users",
+				FilterById:             "customFilterById",
+				FilterByDn:             "customFilterByDn",
+				FilterByLogin:          "customFilterByLogin",
+				FilterGroupsByDn:       "customFilterGroupsByDn",
+				FilterNestedGroupsByDn: "customFilterNestedGroupsByDn",
+				FilterAll:              "customFilterAll",
 			},
 			Groups: &adc.GroupsConfigs{
-				IdAttribute:       "custom-groups-id-attr",
-				Attributes:        []string{"dummy-group-attr"},
-				SearchBase:        "OU=custom-groups",
-				FilterById:        "customFilterById",
-				FilterByDn:        "customFilterByDn",
-				FilterMembersByDn: "customFilterMembersByDn",
+				IdAttribute:             "custom-groups-id-attr",
+				Attributes:              []string{"dummy-group-attr"},
+				SearchBase:              "OU=custom-groups",
+				FilterById:              "customFilterById",
+				FilterByDn:              "customFilterByDn",
+				FilterMembersByDn:       "customFilterMembersByDn",
+				FilterMemberById:        "customFilterMemberById",
+				FilterNestedMembersByDn: "customFilterNestedMembersByDn",
+				FilterAll:               "customFilterAll",
 			},
 		}
 
@@ -95,8 +112,18 @@ func Test_Config(t *testing.T) {
 		require.NotNil(t, cl.Config)
 
 		require.Equal(t, cfg.Timeout, cl.Config.Timeout)
+		require.Equal(t, cfg.PageSize, cl.Config.PageSize)
+		require.Equal(t, cfg.PoolSize, cl.Config.PoolSize)
+		require.Equal(t, cfg.PoolIdleTimeout, cl.Config.PoolIdleTimeout)
+		require.Equal(t, cfg.Reconnect, cl.Config.Reconnect)
+		require.Equal(t, cfg.BinaryAttributes, cl.Config.BinaryAttributes)
 		require.Equal(t, cfg.URL, cl.Config.URL)
 		require.Equal(t, cfg.InsecureTLS, cl.Config.InsecureTLS)
+		require.Equal(t, cfg.URLs, cl.Config.URLs)
+		require.Equal(t, cfg.URLStrategy, cl.Config.URLStrategy)
+		require.Equal(t, cfg.Discovery, cl.Config.Discovery)
+		require.Equal(t, cfg.StartTLS, cl.Config.StartTLS)
+		require.Equal(t, cfg.TLS, cl.Config.TLS)
 		require.Equal(t, cfg.SearchBase, cl.Config.SearchBase)
 		require.Equal(t, cfg.Bind, cl.Config.Bind)
 
@@ -105,7 +132,10 @@ func Test_Config(t *testing.T) {
 		require.Equal(t, cfg.Users.Attributes, cl.Config.Users.Attributes)
 		require.Equal(t, cfg.Users.FilterById, cl.Config.Users.FilterById)
 		require.Equal(t, cfg.Users.FilterByDn, cl.Config.Users.FilterByDn)
+		require.Equal(t, cfg.Users.FilterByLogin, cl.Config.Users.FilterByLogin)
 		require.Equal(t, cfg.Users.FilterGroupsByDn, cl.Config.Users.FilterGroupsByDn)
+		require.Equal(t, cfg.Users.FilterNestedGroupsByDn, cl.Config.Users.FilterNestedGroupsByDn)
+		require.Equal(t, cfg.Users.FilterAll, cl.Config.Users.FilterAll)
 
 		require.Equal(t, cfg.Groups.IdAttribute, cl.Config.Groups.IdAttribute)
 		require.Equal(t, cfg.Groups.SearchBase, cl.Config.Groups.SearchBase)
@@ -113,5 +143,32 @@ func Test_Config(t *testing.T) {
 		require.Equal(t, cfg.Groups.FilterById, cl.Config.Groups.FilterById)
 		require.Equal(t, cfg.Groups.FilterByDn, cl.Config.Groups.FilterByDn)
 		require.Equal(t, cfg.Groups.FilterMembersByDn, cl.Config.Groups.FilterMembersByDn)
+		require.Equal(t, cfg.Groups.FilterMemberById, cl.Config.Groups.FilterMemberById)
+		require.Equal(t, cfg.Groups.FilterNestedMembersByDn, cl.Config.Groups.FilterNestedMembersByDn)
+		require.Equal(t, cfg.Groups.FilterAll, cl.Config.Groups.FilterAll)
 	})
 }
+
+func Test_Config_JSON(t *testing.T) {
+	data := `{
+		"url": "ldaps://dc1.company.com:636",
+		"start_tls": false,
+		"tls": {
+			"ca_file": "/etc/ssl/company-ca.pem",
+			"cert_file": "/etc/ssl/client.pem",
+			"key_file": "/etc/ssl/client.key",
+			"server_name": "dc.company.com",
+			"min_version": "1.3"
+		}
+	}`
+
+	var cfg adc.Config
+	require.NoError(t, json.Unmarshal([]byte(data), &cfg))
+	require.Equal(t, &adc.TLSConfig{
+		CAFile:     "/etc/ssl/company-ca.pem",
+		CertFile:   "/etc/ssl/client.pem",
+		KeyFile:    "/etc/ssl/client.key",
+		ServerName: "dc.company.com",
+		MinVersion: "1.3",
+	}, cfg.TLS)
+}
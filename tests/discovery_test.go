@@ -0,0 +1,134 @@
+package adctests
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+
+	"github.com/dlampsi/adc"
+	"github.com/stretchr/testify/require"
+)
+
+var _ adc.Resolver = net.DefaultResolver
+
+// DNS stand-in returning SRV records by lookup name.
+type fakeResolver struct {
+	mu      sync.Mutex
+	records map[string][]*net.SRV
+	lookups []string
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if service != "" || proto != "" {
+		name = "_" + service + "._" + proto + "." + name
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.lookups = append(r.lookups, name)
+	records, ok := r.records[name]
+	if !ok {
+		return "", nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return name, records, nil
+}
+
+func Test_Client_Discovery(t *testing.T) {
+	newClient := func(t *testing.T, discovery *adc.DiscoveryConfig, resolver adc.Resolver) *adc.Client {
+		cfg := getClientConfig()
+		cfg.URL = ""
+		cfg.Discovery = discovery
+		return adc.New(&cfg, adc.WithLogger(&logger{t: t}), adc.WithResolver(resolver))
+	}
+
+	t.Run("Domain", func(t *testing.T) {
+		resolver := &fakeResolver{records: map[string][]*net.SRV{
+			"_ldap._tcp.adc.dev": {{Target: "127.0.0.1.", Port: 389}},
+		}}
+		cl := newClient(t, &adc.DiscoveryConfig{Domain: "adc.dev", Scheme: "ldaps"}, resolver)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+		require.Equal(t, "ldaps://127.0.0.1:636", cl.ServerURL())
+	})
+	t.Run("SRVPort", func(t *testing.T) {
+		resolver := &fakeResolver{records: map[string][]*net.SRV{
+			"_ldap._tcp.adc.dev": {{Target: "127.0.0.1.", Port: 389}},
+		}}
+		cfg := getClientConfig()
+		cfg.URL = ""
+		cfg.StartTLS = true
+		cfg.Discovery = &adc.DiscoveryConfig{Domain: "adc.dev"}
+		cl := adc.New(&cfg, adc.WithResolver(resolver))
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+		require.Equal(t, "ldap://127.0.0.1:389", cl.ServerURL())
+	})
+	t.Run("Priority", func(t *testing.T) {
+		resolver := &fakeResolver{records: map[string][]*net.SRV{
+			"_ldap._tcp.adc.dev": {
+				{Target: "localhost.", Priority: 10, Weight: 100},
+				{Target: "127.0.0.1.", Priority: 0, Weight: 0},
+			},
+		}}
+		cl := newClient(t, &adc.DiscoveryConfig{Domain: "adc.dev", Scheme: "ldaps"}, resolver)
+		for i := 0; i < 5; i++ {
+			require.NoError(t, cl.Connect())
+			require.Equal(t, "ldaps://127.0.0.1:636", cl.ServerURL(), "Lower priority value should be tried first")
+			require.NoError(t, cl.Disconnect())
+		}
+	})
+	t.Run("Failover", func(t *testing.T) {
+		resolver := &fakeResolver{records: map[string][]*net.SRV{
+			"_ldap._tcp.adc.dev": {
+				{Target: "127.0.0.1.", Priority: 0},
+				{Target: "localhost.", Priority: 1},
+			},
+		}}
+		cl := newClient(t, &adc.DiscoveryConfig{Domain: "adc.dev", Scheme: "ldaps", Port: 1}, resolver)
+		err := cl.Connect()
+		require.ErrorContains(t, err, "ldaps://127.0.0.1:1")
+		require.ErrorContains(t, err, "ldaps://localhost:1", "All discovered servers should be tried")
+	})
+	t.Run("Site", func(t *testing.T) {
+		resolver := &fakeResolver{records: map[string][]*net.SRV{
+			"_ldap._tcp.hq._sites.dc._msdcs.adc.dev": {{Target: "127.0.0.1."}},
+			"_ldap._tcp.adc.dev":                     {{Target: "localhost."}},
+		}}
+		cl := newClient(t, &adc.DiscoveryConfig{Domain: "adc.dev", Site: "hq", Scheme: "ldaps"}, resolver)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+		require.Equal(t, "ldaps://127.0.0.1:636", cl.ServerURL())
+		require.Equal(t, []string{"_ldap._tcp.hq._sites.dc._msdcs.adc.dev"}, resolver.lookups)
+	})
+	t.Run("SiteFallback", func(t *testing.T) {
+		resolver := &fakeResolver{records: map[string][]*net.SRV{
+			"_ldap._tcp.adc.dev": {{Target: "127.0.0.1."}},
+		}}
+		cl := newClient(t, &adc.DiscoveryConfig{Domain: "adc.dev", Site: "branch", Scheme: "ldaps"}, resolver)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+		require.Equal(t, []string{"_ldap._tcp.branch._sites.dc._msdcs.adc.dev", "_ldap._tcp.adc.dev"}, resolver.lookups)
+	})
+	t.Run("NotFound", func(t *testing.T) {
+		cl := newClient(t, &adc.DiscoveryConfig{Domain: "adc.dev"}, &fakeResolver{})
+		err := cl.Connect()
+		var dnsErr *net.DNSError
+		require.True(t, errors.As(err, &dnsErr))
+	})
+	t.Run("ConfiguredURLsFallback", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.Discovery = &adc.DiscoveryConfig{Domain: "adc.dev"}
+		cl := adc.New(&cfg, adc.WithResolver(&fakeResolver{}))
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+		require.Equal(t, cfg.URL, cl.ServerURL())
+	})
+	t.Run("BadConfig", func(t *testing.T) {
+		require.Error(t, newClient(t, &adc.DiscoveryConfig{}, &fakeResolver{}).Connect())
+
+		resolver := &fakeResolver{records: map[string][]*net.SRV{"_ldap._tcp.adc.dev": {{Target: "127.0.0.1."}}}}
+		err := newClient(t, &adc.DiscoveryConfig{Domain: "adc.dev", Scheme: "http"}, resolver).Connect()
+		require.ErrorContains(t, err, "unsupported discovery scheme")
+	})
+}
@@ -0,0 +1,100 @@
+package adctests
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/dlampsi/adc"
+	"github.com/go-ldap/ldap/v3"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_LDAPError(t *testing.T) {
+	t.Run("Is", func(t *testing.T) {
+		for code, target := range map[uint16]error{
+			ldap.LDAPResultEntryAlreadyExists:       adc.ErrAlreadyExists,
+			ldap.LDAPResultAttributeOrValueExists:   adc.ErrAlreadyExists,
+			ldap.LDAPResultInsufficientAccessRights: adc.ErrInsufficientAccess,
+			ldap.ErrorNetwork:                       adc.ErrNotConnected,
+		} {
+			err := &adc.LDAPError{Op: "search", ResultCode: code, Err: ldap.NewError(code, errors.New("test"))}
+			require.ErrorIs(t, err, target)
+		}
+	})
+	t.Run("IsNotFound", func(t *testing.T) {
+		for _, op := range []string{"modify", "delete"} {
+			err := &adc.LDAPError{Op: op, ResultCode: ldap.LDAPResultNoSuchObject, Err: ldap.NewError(ldap.LDAPResultNoSuchObject, errors.New("test"))}
+			require.ErrorIs(t, err, adc.ErrNotFound)
+		}
+
+		err := &adc.LDAPError{Op: "search", ResultCode: ldap.LDAPResultNoSuchObject, Err: ldap.NewError(ldap.LDAPResultNoSuchObject, errors.New("test"))}
+		require.NotErrorIs(t, err, adc.ErrNotFound, "Missing search base isn't a missing entry")
+	})
+	t.Run("IsOther", func(t *testing.T) {
+		err := &adc.LDAPError{ResultCode: ldap.LDAPResultBusy, Err: ldap.NewError(ldap.LDAPResultBusy, errors.New("test"))}
+		require.NotErrorIs(t, err, adc.ErrNotFound)
+		require.NotErrorIs(t, err, adc.ErrAlreadyExists)
+	})
+	t.Run("As", func(t *testing.T) {
+		var err error = &adc.LDAPError{
+			Op:         "add",
+			DN:         "CN=test,DC=adc,DC=dev",
+			ResultCode: ldap.LDAPResultEntryAlreadyExists,
+			MatchedDN:  "DC=adc,DC=dev",
+			Err:        ldap.NewError(ldap.LDAPResultEntryAlreadyExists, errors.New("test")),
+		}
+
+		var lerr *ldap.Error
+		require.ErrorAs(t, err, &lerr, "Original ldap error should be available")
+		require.Equal(t, ldap.LDAPResultEntryAlreadyExists, lerr.ResultCode)
+		require.True(t, ldap.IsErrorWithCode(err, ldap.LDAPResultEntryAlreadyExists))
+	})
+}
+
+func Test_Client_Errors(t *testing.T) {
+	t.Run("NotConnected", func(t *testing.T) {
+		cfg := getClientConfig()
+		cl := adc.New(&cfg)
+		_, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+		require.ErrorIs(t, err, adc.ErrNotConnected)
+	})
+	t.Run("Disconnected", func(t *testing.T) {
+		cfg := getClientConfig()
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		require.NoError(t, cl.Disconnect())
+		_, err := cl.GetGroup(adc.GetGroupArgs{Id: "testgroup1"})
+		require.ErrorIs(t, err, adc.ErrNotConnected)
+	})
+	t.Run("AlreadyExists", func(t *testing.T) {
+		err := tClient.CreateGroup(adc.CreateGroupArgs{Id: "testgroup1"})
+		require.ErrorIs(t, err, adc.ErrAlreadyExists)
+
+		var lerr *adc.LDAPError
+		require.ErrorAs(t, err, &lerr)
+		require.Equal(t, "add", lerr.Op)
+		require.Equal(t, ldap.LDAPResultEntryAlreadyExists, lerr.ResultCode)
+	})
+	t.Run("NotFoundWrapped", func(t *testing.T) {
+		_, err := tClient.AddGroupMembers("nonexists", "testuser1")
+		require.ErrorIs(t, err, adc.ErrNotFound)
+	})
+	t.Run("BadSearchBase", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.SearchBase = "OU=nonexists,DC=adc,DC=dev"
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		err := cl.DeleteUser("testuser1")
+		require.Error(t, err, "Delete shouldn't succeed with missing search base")
+		require.NotErrorIs(t, err, adc.ErrNotFound)
+
+		require.Error(t, cl.DeleteGroup("testgroup1"), "Delete shouldn't succeed with missing search base")
+
+		_, err = cl.Authenticate(context.Background(), "testuser1", "password")
+		var authErr *adc.AuthError
+		require.False(t, errors.As(err, &authErr), "Missing search base isn't an authentication failure")
+	})
+}
@@ -1,10 +1,14 @@
 package adctests
 
 import (
+	"context"
+	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/dlampsi/adc"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -35,6 +39,56 @@ func Test_Group_GetStringAttribute(t *testing.T) {
 		}
 		require.Equal(t, "value", g.GetStringAttribute("one"))
 	})
+	t.Run("MultiValued", func(t *testing.T) {
+		g := &adc.Group{
+			Attributes: map[string]interface{}{
+				"multi": []string{"first", "second"},
+			},
+		}
+		require.Equal(t, "first", g.GetStringAttribute("multi"))
+	})
+}
+
+func Test_Group_GetBytesAttribute(t *testing.T) {
+	g := &adc.Group{
+		Attributes: map[string]interface{}{
+			"one": []byte("value"),
+			"str": "string",
+		},
+	}
+	require.Equal(t, []byte("value"), g.GetBytesAttribute("one"))
+	require.Nil(t, g.GetBytesAttribute("str"))
+}
+
+func Test_Group_GetStringsAttribute(t *testing.T) {
+	g := &adc.Group{
+		Attributes: map[string]interface{}{
+			"one":   "value",
+			"multi": []string{"first", "second"},
+			"two":   2,
+		},
+	}
+	require.Equal(t, []string{"value"}, g.GetStringsAttribute("one"))
+	require.Equal(t, []string{"first", "second"}, g.GetStringsAttribute("multi"))
+	require.Nil(t, g.GetStringsAttribute("two"))
+	require.Nil(t, g.GetStringsAttribute("nonexists"))
+}
+
+func Test_Group_GetTypedAttributes(t *testing.T) {
+	g := &adc.Group{
+		Attributes: map[string]interface{}{
+			"groupType":   "-2147483646",
+			"whenChanged": "20240101120000.0Z",
+		},
+	}
+
+	groupType, ok := g.GetIntAttribute("groupType")
+	require.True(t, ok)
+	require.Equal(t, int64(-2147483646), groupType)
+
+	changed, ok := g.GetTimeAttribute("whenChanged")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), changed)
 }
 
 func Test_GetGroupRequest_Validate(t *testing.T) {
@@ -101,7 +155,7 @@ func Test_Client_GetGroup(t *testing.T) {
 			Id: "nonexists",
 		}
 		group, err := cl.GetGroup(req)
-		require.NoError(t, err, "Expected no error on non exists group")
+		require.ErrorIs(t, err, adc.ErrNotFound)
 		require.Nil(t, group, "Non exists group error should return nil")
 	})
 	t.Run("TooManyEntries", func(t *testing.T) {
@@ -109,7 +163,7 @@ func Test_Client_GetGroup(t *testing.T) {
 			Filter: "(&(objectClass=group))",
 		}
 		group, err := cl.GetGroup(req)
-		require.Error(t, err, "Expected error on too many entries")
+		require.ErrorIs(t, err, adc.ErrTooManyEntries)
 		require.Nil(t, group, "Group should be nil on error")
 	})
 	t.Run("OkById", func(t *testing.T) {
@@ -133,6 +187,49 @@ func Test_Client_GetGroup(t *testing.T) {
 		require.Equal(t, req.Id, group.Id)
 		require.Empty(t, group.Members)
 	})
+	t.Run("OkWithNestedMembers", func(t *testing.T) {
+		req := adc.GetGroupArgs{
+			Id:                   "testgroup3",
+			ResolveNestedMembers: true,
+		}
+		group, err := cl.GetGroup(req)
+		require.NoError(t, err)
+		require.NotNil(t, group)
+
+		var found bool
+		for _, m := range group.Members {
+			if m.Id == "testuser1" {
+				found = true
+				require.True(t, m.Inherited, "Member of nested group should be marked as inherited")
+			}
+		}
+		require.True(t, found, "Member of nested group should be resolved")
+	})
+	t.Run("OkWithGroupMember", func(t *testing.T) {
+		group, err := cl.GetGroup(adc.GetGroupArgs{Id: "testgroup3"})
+		require.NoError(t, err)
+		require.NotNil(t, group)
+		require.Contains(t, group.MembersId(), "testgroup1", "Group member should be returned")
+		for _, m := range group.Members {
+			if m.Id == "testgroup1" {
+				require.Equal(t, adc.ObjectTypeGroup, m.Type)
+				require.Equal(t, "CN=testgroup1,CN=Users,DC=adc,DC=dev", m.DN)
+			}
+		}
+	})
+	t.Run("OkWithMembersOutsideUsersSearchBase", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.Users = &adc.UsersConfigs{SearchBase: "OU=Domain Controllers,DC=adc,DC=dev"}
+		cfg.Groups = &adc.GroupsConfigs{SearchBase: cfg.SearchBase}
+		ouCl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+		require.NoError(t, ouCl.Connect())
+
+		group, err := ouCl.GetGroup(adc.GetGroupArgs{Id: "testgroup2"})
+		require.NoError(t, err)
+		require.NotNil(t, group)
+		require.Contains(t, group.MembersId(), "testuser2")
+		require.Contains(t, group.MembersDn(), "CN=testuser2,CN=Users,DC=adc,DC=dev")
+	})
 	t.Run("OkByDn", func(t *testing.T) {
 		req := adc.GetGroupArgs{
 			Dn: "CN=testgroup2,CN=Users,DC=adc,DC=dev",
@@ -151,6 +248,17 @@ func Test_Client_GetGroup(t *testing.T) {
 		require.NotNil(t, group)
 		require.Equal(t, "testgroup2", group.Id)
 	})
+	t.Run("OkWithMultiValuedAttributes", func(t *testing.T) {
+		req := adc.GetGroupArgs{
+			Id:         "testgroup2",
+			Attributes: []string{"sAMAccountName", "objectClass"},
+		}
+		group, err := cl.GetGroup(req)
+		require.NoError(t, err)
+		require.NotNil(t, group)
+		require.Contains(t, group.GetStringsAttribute("objectClass"), "group")
+		require.Equal(t, "testgroup2", group.GetStringAttribute("sAMAccountName"))
+	})
 	t.Run("OkWithAttributes", func(t *testing.T) {
 		req := adc.GetGroupArgs{
 			Id:         "testgroup2",
@@ -165,6 +273,69 @@ func Test_Client_GetGroup(t *testing.T) {
 	})
 }
 
+func Test_Client_ListGroups(t *testing.T) {
+	cfg := getClientConfig()
+	cfg.PageSize = 1
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	t.Run("Ok", func(t *testing.T) {
+		groups, err := cl.ListGroups(adc.ListGroupsArgs{})
+		require.NoError(t, err)
+		require.Greater(t, len(groups), 1, "Expected all groups across pages")
+
+		var ids []string
+		for _, g := range groups {
+			ids = append(ids, g.Id)
+		}
+		require.Contains(t, ids, "testgroup1")
+		require.Contains(t, ids, "testgroup2")
+	})
+	t.Run("OkWithFilter", func(t *testing.T) {
+		groups, err := cl.ListGroups(adc.ListGroupsArgs{
+			Filter:            "(&(objectClass=group)(cn=testgroup*))",
+			SkipMembersSearch: true,
+		})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(groups), 2)
+		for _, g := range groups {
+			require.Empty(t, g.Members)
+		}
+	})
+	t.Run("BadSearchBase", func(t *testing.T) {
+		_, err := cl.ListGroups(adc.ListGroupsArgs{SearchBase: "OU=nonexists,DC=adc,DC=dev"})
+		require.Error(t, err)
+	})
+}
+
+func Test_Client_IterGroups(t *testing.T) {
+	cfg := getClientConfig()
+	cfg.PageSize = 1
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	t.Run("Ok", func(t *testing.T) {
+		it := cl.IterGroups(context.Background(), adc.ListGroupsArgs{
+			Filter: "(&(objectClass=group)(cn=testgroup*))",
+		})
+		defer it.Close()
+
+		var ids []string
+		for it.Next() {
+			ids = append(ids, it.Group().Id)
+		}
+		require.NoError(t, it.Err())
+		require.Contains(t, ids, "testgroup1")
+		require.Contains(t, ids, "testgroup2")
+	})
+	t.Run("StopEarly", func(t *testing.T) {
+		it := cl.IterGroups(context.Background(), adc.ListGroupsArgs{})
+		require.True(t, it.Next())
+		require.NoError(t, it.Close())
+		require.False(t, it.Next())
+	})
+}
+
 func Test_Client_AddGroupMembers(t *testing.T) {
 	cfg := getClientConfig()
 	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
@@ -177,7 +348,7 @@ func Test_Client_AddGroupMembers(t *testing.T) {
 	})
 	t.Run("ErrNonExistsGroup", func(t *testing.T) {
 		cnt, err := cl.AddGroupMembers("nonexists", "someDn")
-		require.Error(t, err, "Expected error on bad group id")
+		require.ErrorIs(t, err, adc.ErrNotFound)
 		require.Zero(t, cnt, "Added members count should be zero on error")
 	})
 	t.Run("AlreadyAMember", func(t *testing.T) {
@@ -195,6 +366,13 @@ func Test_Client_AddGroupMembers(t *testing.T) {
 		require.NoError(t, err, "No error on non exists member")
 		require.Equal(t, 0, cnt, "Added members count should be zero on error")
 	})
+	t.Run("WithContextCancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cnt, err := cl.AddGroupMembersContext(ctx, "testgroup1", "testuser2")
+		require.ErrorIs(t, err, context.Canceled)
+		require.Zero(t, cnt)
+	})
 	t.Run("Ok", func(t *testing.T) {
 		const (
 			groupId  = "testgroup1"
@@ -219,6 +397,192 @@ func Test_Client_AddGroupMembers(t *testing.T) {
 	})
 }
 
+func Test_Client_AddGroupMembers_NonUserMembers(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	const (
+		groupId  = "testgroup2"
+		memberId = "testgroup1"
+		memberDn = "CN=testgroup1,CN=Users,DC=adc,DC=dev"
+	)
+
+	getMember := func(t *testing.T) *adc.GroupMember {
+		group, err := cl.GetGroup(adc.GetGroupArgs{Id: groupId})
+		require.NoError(t, err)
+		require.NotNil(t, group)
+		for _, m := range group.Members {
+			if m.Id == memberId {
+				return &m
+			}
+		}
+		return nil
+	}
+
+	t.Run("ById", func(t *testing.T) {
+		cnt, err := cl.AddGroupMembers(groupId, memberId)
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+
+		member := getMember(t)
+		require.NotNil(t, member, "Group should be added as a member")
+		require.Equal(t, adc.ObjectTypeGroup, member.Type)
+
+		cnt, err = cl.DeleteGroupMembers(groupId, memberId)
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+		require.Nil(t, getMember(t))
+	})
+	t.Run("ByDn", func(t *testing.T) {
+		cnt, err := cl.AddGroupMembers(groupId, memberDn)
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+		require.NotNil(t, getMember(t), "Group should be added as a member")
+
+		cnt, err = cl.DeleteGroupMembers(groupId, memberDn)
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+		require.Nil(t, getMember(t))
+	})
+	t.Run("NonExistsDn", func(t *testing.T) {
+		cnt, err := cl.AddGroupMembers(groupId, "CN=nonexists,CN=Users,DC=adc,DC=dev")
+		require.NoError(t, err, "No error on non exists member")
+		require.Zero(t, cnt)
+	})
+	t.Run("ManyMembers", func(t *testing.T) {
+		// More members than resolved by single search request.
+		members := []string{memberId, "CN=testuser2,CN=Users,DC=adc,DC=dev"}
+		for i := 0; i < 250; i++ {
+			members = append(members, fmt.Sprintf("nonexists%d", i))
+		}
+
+		cnt, err := cl.AddGroupMembers(groupId, members...)
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt, "Only group should be added, cause user is already a member")
+		require.NotNil(t, getMember(t))
+
+		cnt, err = cl.DeleteGroupMembers(groupId, members...)
+		require.NoError(t, err)
+		require.Equal(t, 2, cnt)
+
+		cnt, err = cl.AddGroupMembers(groupId, "testuser2")
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+	})
+}
+
+func Test_Client_AddGroupMembers_ComputerNames(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	const groupId = "testgroup2"
+	ts := time.Now().Format("20060102150405")
+	userId := "web" + ts
+	computerId := "pc" + ts
+
+	// User 'webX' and computer 'webX$' with the same name.
+	require.NoError(t, cl.CreateUser(adc.CreateUserArgs{Id: userId, Password: "Initial-Passw0rd"}))
+	defer func() { require.NoError(t, cl.DeleteUser(userId)) }()
+	require.NoError(t, cl.CreateUser(adc.CreateUserArgs{
+		Id:       userId + "-pc",
+		Password: "Initial-Passw0rd",
+		Attributes: map[string][]string{
+			"objectClass":    {"computer"},
+			"sAMAccountName": {userId + "$"},
+		},
+	}))
+	defer func() { require.NoError(t, cl.DeleteUser(userId+"$")) }()
+	// Computer without user with the same name.
+	require.NoError(t, cl.CreateUser(adc.CreateUserArgs{
+		Id:       computerId,
+		Password: "Initial-Passw0rd",
+		Attributes: map[string][]string{
+			"objectClass":    {"computer"},
+			"sAMAccountName": {computerId + "$"},
+		},
+	}))
+	defer func() { require.NoError(t, cl.DeleteUser(computerId+"$")) }()
+
+	memberTypes := func(t *testing.T) map[string]adc.ObjectType {
+		group, err := cl.GetGroup(adc.GetGroupArgs{Id: groupId})
+		require.NoError(t, err)
+		result := make(map[string]adc.ObjectType)
+		for _, m := range group.Members {
+			result[m.Id] = m.Type
+		}
+		return result
+	}
+
+	t.Run("ExactNameFirst", func(t *testing.T) {
+		cnt, err := cl.AddGroupMembers(groupId, userId)
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+		require.Equal(t, adc.ObjectTypeUser, memberTypes(t)[userId])
+		require.NotContains(t, memberTypes(t), userId+"$")
+
+		cnt, err = cl.DeleteGroupMembers(groupId, userId)
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+	})
+	t.Run("ComputerNameFallback", func(t *testing.T) {
+		cnt, err := cl.AddGroupMembers(groupId, computerId)
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+		require.Equal(t, adc.ObjectTypeComputer, memberTypes(t)[computerId+"$"])
+
+		cnt, err = cl.DeleteGroupMembers(groupId, computerId+"$")
+		require.NoError(t, err)
+		require.Equal(t, 1, cnt)
+	})
+}
+
+func Test_Client_GroupMembers_Concurrent(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	const groupId = "testgroup1"
+
+	userReq := adc.CreateUserArgs{
+		Id:       "userForConcurrentAdd" + time.Now().Format("20060102150405"),
+		Password: "Initial-Passw0rd",
+	}
+	require.NoError(t, cl.CreateUser(userReq))
+	defer func() { require.NoError(t, cl.DeleteUser(userReq.Id)) }()
+
+	membersIds := []string{"testuser2", userReq.Id}
+
+	var wg sync.WaitGroup
+	for _, id := range membersIds {
+		wg.Add(1)
+		go func(id string) {
+			defer wg.Done()
+			cnt, err := cl.AddGroupMembers(groupId, id)
+			assert.NoError(t, err)
+			assert.Equal(t, 1, cnt)
+		}(id)
+	}
+	wg.Wait()
+
+	group, err := cl.GetGroup(adc.GetGroupArgs{Id: groupId})
+	require.NoError(t, err)
+	require.NotNil(t, group)
+	require.Contains(t, group.MembersId(), "testuser1", "Existing members should be kept")
+	for _, id := range membersIds {
+		require.Contains(t, group.MembersId(), id, "Concurrent additions shouldn't undo each other")
+	}
+
+	cnt, err := cl.DeleteGroupMembers(groupId, membersIds...)
+	require.NoError(t, err)
+	require.Equal(t, len(membersIds), cnt)
+
+	group, err = cl.GetGroup(adc.GetGroupArgs{Id: groupId})
+	require.NoError(t, err)
+	require.Equal(t, []string{"testuser1"}, group.MembersId())
+}
+
 func Test_Client_DeleteGroupMembers(t *testing.T) {
 	cfg := getClientConfig()
 	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
@@ -231,7 +595,7 @@ func Test_Client_DeleteGroupMembers(t *testing.T) {
 	})
 	t.Run("ErrNonExistsGroup", func(t *testing.T) {
 		cnt, err := cl.DeleteGroupMembers("nonexists", "someDn")
-		require.Error(t, err, "Expected error on bad group id")
+		require.ErrorIs(t, err, adc.ErrNotFound)
 		require.Zero(t, cnt, "Added groups count should be zero on error")
 	})
 	t.Run("BadMember", func(t *testing.T) {
@@ -244,6 +608,13 @@ func Test_Client_DeleteGroupMembers(t *testing.T) {
 		require.NoError(t, err, "No error on non exists member")
 		require.Equal(t, 0, cnt, "Deleted members count should be zero on error")
 	})
+	t.Run("WithContextCancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		cnt, err := cl.DeleteGroupMembersContext(ctx, "testgroup2", "testuser2")
+		require.ErrorIs(t, err, context.Canceled)
+		require.Zero(t, cnt)
+	})
 	t.Run("AlreadyNotAMember", func(t *testing.T) {
 		cnt, err := cl.DeleteGroupMembers("testgroup1", "testuser2")
 		require.NoError(t, err, "Expected no error on already a member")
@@ -273,6 +644,96 @@ func Test_Client_DeleteGroupMembers(t *testing.T) {
 	})
 }
 
+func Test_Client_SyncGroupMembers(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	t.Run("BadArgs", func(t *testing.T) {
+		report, err := cl.SyncGroupMembers(adc.SyncGroupMembersArgs{})
+		require.Error(t, err)
+		require.Nil(t, report)
+
+		report, err = cl.SyncGroupMembers(adc.SyncGroupMembersArgs{GroupId: "testgroup1", Members: []string{""}})
+		require.Error(t, err)
+		require.Nil(t, report)
+	})
+	t.Run("ErrNonExistsGroup", func(t *testing.T) {
+		report, err := cl.SyncGroupMembers(adc.SyncGroupMembersArgs{GroupId: "nonexists"})
+		require.ErrorIs(t, err, adc.ErrNotFound)
+		require.Nil(t, report)
+	})
+
+	groupReq := adc.CreateGroupArgs{Id: "groupForSync" + time.Now().Format("20060102150405")}
+	require.NoError(t, cl.CreateGroup(groupReq))
+	defer func() { require.NoError(t, cl.DeleteGroup(groupReq.Id)) }()
+
+	cnt, err := cl.AddGroupMembers(groupReq.Id, "testuser1")
+	require.NoError(t, err)
+	require.Equal(t, 1, cnt)
+
+	args := adc.SyncGroupMembersArgs{
+		GroupId: groupReq.Id,
+		Members: []string{"testuser2", "CN=testgroup1,CN=Users,DC=adc,DC=dev", "nonexists"},
+	}
+
+	t.Run("DryRun", func(t *testing.T) {
+		dryArgs := args
+		dryArgs.DryRun = true
+		report, err := cl.SyncGroupMembers(dryArgs)
+		require.ErrorIs(t, err, adc.ErrUnresolvedMembers)
+		require.True(t, report.DryRun)
+		require.True(t, report.HasChanges())
+		require.ElementsMatch(t, []string{"CN=testuser2,CN=Users,DC=adc,DC=dev", "CN=testgroup1,CN=Users,DC=adc,DC=dev"}, report.Added)
+		require.Equal(t, []string{"CN=testuser1,CN=Users,DC=adc,DC=dev"}, report.Removed)
+		require.Equal(t, []string{"nonexists"}, report.Unresolved)
+
+		group, err := cl.GetGroup(adc.GetGroupArgs{Id: groupReq.Id})
+		require.NoError(t, err)
+		require.Equal(t, []string{"testuser1"}, group.MembersId(), "Members shouldn't be changed in dry-run mode")
+	})
+	t.Run("ErrUnresolved", func(t *testing.T) {
+		report, err := cl.SyncGroupMembers(args)
+		require.ErrorIs(t, err, adc.ErrUnresolvedMembers)
+		require.Equal(t, []string{"nonexists"}, report.Unresolved)
+		require.False(t, report.HasChanges())
+
+		group, err := cl.GetGroup(adc.GetGroupArgs{Id: groupReq.Id})
+		require.NoError(t, err)
+		require.Equal(t, []string{"testuser1"}, group.MembersId(), "Members shouldn't be changed if some members are unresolved")
+	})
+
+	args.AllowUnresolved = true
+
+	t.Run("Ok", func(t *testing.T) {
+		report, err := cl.SyncGroupMembers(args)
+		require.NoError(t, err)
+		require.False(t, report.DryRun)
+		require.Len(t, report.Added, 2)
+		require.Len(t, report.Removed, 1)
+		require.Equal(t, []string{"nonexists"}, report.Unresolved)
+
+		group, err := cl.GetGroup(adc.GetGroupArgs{Id: groupReq.Id})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{"testuser2", "testgroup1"}, group.MembersId())
+	})
+	t.Run("NoChanges", func(t *testing.T) {
+		report, err := cl.SyncGroupMembers(args)
+		require.NoError(t, err)
+		require.False(t, report.HasChanges(), "Second sync should be no-op")
+	})
+	t.Run("RemoveAll", func(t *testing.T) {
+		report, err := cl.SyncGroupMembers(adc.SyncGroupMembersArgs{GroupId: groupReq.Id})
+		require.NoError(t, err)
+		require.Len(t, report.Removed, 2)
+		require.Empty(t, report.Added)
+
+		group, err := cl.GetGroup(adc.GetGroupArgs{Id: groupReq.Id})
+		require.NoError(t, err)
+		require.Empty(t, group.Members)
+	})
+}
+
 func Test_Client_CreateGroup(t *testing.T) {
 	cfg := getClientConfig()
 	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
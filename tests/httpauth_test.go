@@ -0,0 +1,127 @@
+package adctests
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/dlampsi/adc"
+	"github.com/dlampsi/adc/httpauth"
+	"github.com/stretchr/testify/require"
+)
+
+var _ httpauth.Authenticator = (*adc.Client)(nil)
+
+type fakeAuthenticator struct {
+	calls atomic.Int32
+	users map[string]*adc.User
+}
+
+func (a *fakeAuthenticator) AuthenticateWithPolicy(ctx context.Context, login, password string, policy adc.AuthPolicy) (*adc.AuthResult, error) {
+	a.calls.Add(1)
+	user, ok := a.users[login]
+	if !ok || password != "secret" {
+		return nil, &adc.AuthError{Reason: adc.AuthReasonInvalidCredentials}
+	}
+	return &adc.AuthResult{User: user, Decision: policy.Evaluate(user)}, nil
+}
+
+func Test_HttpAuth_Middleware(t *testing.T) {
+	auth := &fakeAuthenticator{
+		users: map[string]*adc.User{
+			"admin": {Id: "admin", Groups: []adc.UserGroup{{Id: "admins"}, {Id: "staff", Inherited: true}}},
+			"user":  {Id: "user", Groups: []adc.UserGroup{{Id: "staff"}}},
+		},
+	}
+	mw := httpauth.New(auth, httpauth.WithRealm("tests"), httpauth.WithCacheTTL(100*time.Millisecond))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user := httpauth.UserFromContext(r.Context())
+		require.NotNil(t, user, "User should be stored in request context")
+		_, _ = w.Write([]byte(user.Id))
+	})
+	mux := http.NewServeMux()
+	mux.Handle("/", mw.Handler(handler))
+	mux.Handle("/admin", mw.Require(adc.AuthPolicy{RequiredGroups: []string{"admins"}})(handler))
+	mux.Handle("/staff", mw.Require(adc.AuthPolicy{RequiredGroups: []string{"staff"}, NestedGroups: true})(handler))
+
+	do := func(path, login, password string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if login != "" {
+			req.SetBasicAuth(login, password)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("NoCredentials", func(t *testing.T) {
+		rec := do("/", "", "")
+		require.Equal(t, http.StatusUnauthorized, rec.Code)
+		require.Equal(t, `Basic realm="tests", charset="UTF-8"`, rec.Header().Get("WWW-Authenticate"))
+	})
+	t.Run("WrongPassword", func(t *testing.T) {
+		require.Equal(t, http.StatusUnauthorized, do("/", "user", "wrong").Code)
+	})
+	t.Run("Ok", func(t *testing.T) {
+		rec := do("/", "user", "secret")
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Equal(t, "user", rec.Body.String())
+	})
+	t.Run("RoutePolicy", func(t *testing.T) {
+		require.Equal(t, http.StatusForbidden, do("/admin", "user", "secret").Code)
+		require.Equal(t, http.StatusOK, do("/admin", "admin", "secret").Code)
+		require.Equal(t, http.StatusOK, do("/staff", "admin", "secret").Code, "Nested group should be evaluated")
+	})
+	t.Run("Cache", func(t *testing.T) {
+		time.Sleep(150 * time.Millisecond)
+		calls := auth.calls.Load()
+
+		require.Equal(t, http.StatusOK, do("/", "user", "secret").Code)
+		require.Equal(t, http.StatusOK, do("/staff", "user", "secret").Code)
+		require.Equal(t, calls+1, auth.calls.Load(), "Successful authentication should be cached")
+
+		require.Equal(t, http.StatusUnauthorized, do("/", "user", "wrong").Code)
+		require.Equal(t, calls+2, auth.calls.Load(), "Cached user shouldn't be returned for other password")
+
+		time.Sleep(150 * time.Millisecond)
+		require.Equal(t, http.StatusOK, do("/", "user", "secret").Code)
+		require.Equal(t, calls+3, auth.calls.Load(), "Expired authentication should be checked again")
+	})
+	t.Run("CachedUserCopy", func(t *testing.T) {
+		auth := &fakeAuthenticator{
+			users: map[string]*adc.User{
+				"user": {
+					Id:         "user",
+					Groups:     []adc.UserGroup{{Id: "staff"}},
+					Attributes: map[string]interface{}{"mail": []string{"user@company.com"}},
+				},
+			},
+		}
+		mw := httpauth.New(auth, httpauth.WithCacheTTL(time.Minute))
+
+		var seen []*adc.User
+		handler := mw.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			user := httpauth.UserFromContext(r.Context())
+			seen = append(seen, user)
+			require.Equal(t, "staff", user.Groups[0].Id)
+			require.Equal(t, "user@company.com", user.GetStringAttribute("mail"))
+
+			user.Groups[0].Id = "admins"
+			user.Attributes["mail"].([]string)[0] = "changed@company.com"
+		}))
+
+		for i := 0; i < 3; i++ {
+			req := httptest.NewRequest(http.MethodGet, "/", nil)
+			req.SetBasicAuth("user", "secret")
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+		}
+		require.Equal(t, int32(1), auth.calls.Load())
+		require.NotSame(t, seen[1], seen[2], "Each request should get own copy of cached user")
+	})
+}
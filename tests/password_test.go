@@ -0,0 +1,120 @@
+package adctests
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dlampsi/adc"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_SetUserPassword(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	req := adc.CreateUserArgs{
+		Id:       "userForPwdSet" + time.Now().Format("20060102150405"),
+		Password: "Initial-Passw0rd",
+	}
+	require.NoError(t, cl.CreateUser(req))
+	defer func() { require.NoError(t, cl.DeleteUser(req.Id)) }()
+	require.NoError(t, cl.EnableUser(req.Id))
+
+	user, err := cl.GetUser(adc.GetUserArgs{Id: req.Id, SkipGroupsSearch: true})
+	require.NoError(t, err)
+	require.NotNil(t, user)
+
+	t.Run("CreatedUserCanLogIn", func(t *testing.T) {
+		require.NoError(t, cl.CheckAuthByDN(user.DN, req.Password))
+	})
+	t.Run("BadArgs", func(t *testing.T) {
+		require.Error(t, cl.SetUserPassword(req.Id, ""))
+	})
+	t.Run("NonExists", func(t *testing.T) {
+		require.Error(t, cl.SetUserPassword("nonexists", "New-Passw0rd"))
+	})
+	t.Run("WeakPassword", func(t *testing.T) {
+		err := cl.SetUserPassword(req.Id, "1")
+		require.ErrorIs(t, err, adc.ErrPasswordPolicy)
+		require.NoError(t, cl.CheckAuthByDN(user.DN, req.Password), "Password shouldn't be changed")
+	})
+	t.Run("Ok", func(t *testing.T) {
+		require.NoError(t, cl.SetUserPassword(req.Id, "Reset-Passw0rd"))
+		require.NoError(t, cl.CheckAuthByDN(user.DN, "Reset-Passw0rd"))
+		require.Error(t, cl.CheckAuthByDN(user.DN, req.Password))
+	})
+}
+
+func Test_Client_ChangeUserPassword(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	req := adc.CreateUserArgs{
+		Id:       "userForPwdChange" + time.Now().Format("20060102150405"),
+		Password: "Initial-Passw0rd",
+	}
+	require.NoError(t, cl.CreateUser(req))
+	defer func() { require.NoError(t, cl.DeleteUser(req.Id)) }()
+	require.NoError(t, cl.EnableUser(req.Id))
+
+	user, err := cl.GetUser(adc.GetUserArgs{Id: req.Id, SkipGroupsSearch: true})
+	require.NoError(t, err)
+	require.NotNil(t, user)
+
+	t.Run("BadArgs", func(t *testing.T) {
+		require.Error(t, cl.ChangeUserPassword(req.Id, "", "New-Passw0rd"))
+		require.Error(t, cl.ChangeUserPassword(req.Id, req.Password, ""))
+	})
+	t.Run("WrongOldPassword", func(t *testing.T) {
+		err := cl.ChangeUserPassword(req.Id, "Wrong-Passw0rd", "New-Passw0rd")
+		require.ErrorIs(t, err, adc.ErrWrongPassword)
+	})
+	t.Run("Ok", func(t *testing.T) {
+		require.NoError(t, cl.ChangeUserPassword(req.Id, req.Password, "Changed-Passw0rd"))
+		require.NoError(t, cl.CheckAuthByDN(user.DN, "Changed-Passw0rd"))
+	})
+}
+
+func Test_Client_CreateUser_WeakPassword(t *testing.T) {
+	cfg := getClientConfig()
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	req := adc.CreateUserArgs{
+		Id:       "userForPwdWeak" + time.Now().Format("20060102150405"),
+		Password: "1",
+	}
+	err := cl.CreateUser(req)
+	defer func() { require.NoError(t, cl.DeleteUser(req.Id)) }()
+	require.ErrorIs(t, err, adc.ErrPasswordPolicy)
+}
+
+func Test_Client_Password_InsecureConnection(t *testing.T) {
+	cfg := getClientConfig()
+	cfg.URL = "ldap://127.0.0.1:389"
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+	defer cl.Disconnect()
+
+	t.Run("SetUserPassword", func(t *testing.T) {
+		err := cl.SetUserPassword("testuser1", "New-Passw0rd")
+		require.ErrorIs(t, err, adc.ErrInsecureConnection)
+	})
+	t.Run("ChangeUserPassword", func(t *testing.T) {
+		err := cl.ChangeUserPassword("testuser1", "Old-Passw0rd", "New-Passw0rd")
+		require.ErrorIs(t, err, adc.ErrInsecureConnection)
+	})
+	t.Run("CreateUser", func(t *testing.T) {
+		req := adc.CreateUserArgs{
+			Id:       "userForPwdInsecure" + time.Now().Format("20060102150405"),
+			Password: "Initial-Passw0rd",
+		}
+		err := cl.CreateUser(req)
+		require.ErrorIs(t, err, adc.ErrInsecureConnection)
+
+		_, err = cl.GetUser(adc.GetUserArgs{Id: req.Id, SkipGroupsSearch: true})
+		require.ErrorIs(t, err, adc.ErrNotFound, "User shouldn't be created without password")
+	})
+}
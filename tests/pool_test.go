@@ -0,0 +1,175 @@
+package adctests
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dlampsi/adc"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Client_Pool(t *testing.T) {
+	t.Run("ConcurrentOperations", func(t *testing.T) {
+		proxy := newTCPProxy(t, "127.0.0.1:636")
+
+		cfg := getClientConfig()
+		cfg.URL = proxy.url()
+		cfg.PoolSize = 2
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 20)
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+				errs <- err
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		require.LessOrEqual(t, proxy.acceptedConns(), cfg.PoolSize, "Pool size should limit open connections")
+	})
+
+	t.Run("IteratorWithOperations", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.PoolSize = 2
+		cfg.PageSize = 1
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		it := cl.IterUsers(context.Background(), adc.ListUsersArgs{})
+		defer it.Close()
+
+		var count int
+		for it.Next() {
+			count++
+			_, err := cl.GetGroup(adc.GetGroupArgs{Id: "testgroup1", SkipMembersSearch: true})
+			require.NoError(t, err)
+		}
+		require.NoError(t, it.Err())
+		require.NotZero(t, count)
+	})
+
+	t.Run("ConcurrentIterators", func(t *testing.T) {
+		proxy := newTCPProxy(t, "127.0.0.1:636")
+
+		cfg := getClientConfig()
+		cfg.URL = proxy.url()
+		cfg.PoolSize = 2
+		cfg.PageSize = 1
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		// Each iterator holds a connection and fetches groups and members using another one.
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		var wg sync.WaitGroup
+		errs := make(chan error, 2*cfg.PoolSize)
+		for i := 0; i < cfg.PoolSize; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				it := cl.IterUsers(ctx, adc.ListUsersArgs{})
+				defer it.Close()
+				for it.Next() {
+				}
+				errs <- it.Err()
+			}()
+			go func() {
+				defer wg.Done()
+				it := cl.IterGroups(ctx, adc.ListGroupsArgs{})
+				defer it.Close()
+				for it.Next() {
+				}
+				errs <- it.Err()
+			}()
+		}
+		wg.Wait()
+		close(errs)
+		for err := range errs {
+			require.NoError(t, err)
+		}
+		require.LessOrEqual(t, proxy.acceptedConns(), cfg.PoolSize, "Iterators should be limited by pool size")
+	})
+
+	t.Run("WaitWithContextCancel", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.PoolSize = 3
+		cfg.PageSize = 1
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		// Each iterator holds a connection until closed. Iterators can use all connections but one.
+		for i := 0; i < 2; i++ {
+			it := cl.IterUsers(context.Background(), adc.ListUsersArgs{SkipGroupsSearch: true})
+			defer it.Close()
+			require.True(t, it.Next())
+		}
+
+		_, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+		require.NoError(t, err, "Iterators shouldn't block other operations")
+
+		ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+		defer cancel()
+		it := cl.IterUsers(ctx, adc.ListUsersArgs{SkipGroupsSearch: true})
+		defer it.Close()
+		require.False(t, it.Next())
+		require.ErrorIs(t, it.Err(), context.DeadlineExceeded)
+	})
+
+	t.Run("IdleTimeout", func(t *testing.T) {
+		proxy := newTCPProxy(t, "127.0.0.1:636")
+
+		cfg := getClientConfig()
+		cfg.URL = proxy.url()
+		cfg.PoolIdleTimeout = 50 * time.Millisecond
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+		require.Equal(t, 1, proxy.activeConns())
+
+		require.Eventually(t, func() bool { return proxy.activeConns() == 0 }, time.Second, 10*time.Millisecond,
+			"Idle connection should be closed without any operations")
+
+		_, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+		require.NoError(t, err, "New connection should be opened after idle timeout")
+	})
+	t.Run("NoIdleTimeout", func(t *testing.T) {
+		proxy := newTCPProxy(t, "127.0.0.1:636")
+
+		cfg := getClientConfig()
+		cfg.URL = proxy.url()
+		cfg.PoolIdleTimeout = -1
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, 1, proxy.activeConns())
+
+		require.NoError(t, cl.Disconnect())
+		require.Eventually(t, func() bool { return proxy.activeConns() == 0 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("Disconnected", func(t *testing.T) {
+		cfg := getClientConfig()
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		require.NoError(t, cl.Disconnect())
+
+		_, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+		require.ErrorIs(t, err, adc.ErrNotConnected)
+	})
+}
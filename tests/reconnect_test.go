@@ -0,0 +1,271 @@
+package adctests
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dlampsi/adc"
+	"github.com/stretchr/testify/require"
+)
+
+// TCP proxy to the test AD server for simulating connection loss.
+type tcpProxy struct {
+	t      *testing.T
+	addr   string
+	target string
+
+	mu       sync.Mutex
+	ln       net.Listener
+	conns    []net.Conn
+	accepted int
+	// Number of proxied connections not closed yet.
+	active int
+
+	// Forwarding waits while write lock is held.
+	gate sync.RWMutex
+}
+
+func newTCPProxy(t *testing.T, target string) *tcpProxy {
+	p := &tcpProxy{t: t, addr: "127.0.0.1:0", target: target}
+	p.start()
+	t.Cleanup(p.stop)
+	return p
+}
+
+// Returns LDAPS URL of the proxy.
+func (p *tcpProxy) url() string {
+	return "ldaps://" + p.addr
+}
+
+func (p *tcpProxy) start() {
+	ln, err := net.Listen("tcp", p.addr)
+	require.NoError(p.t, err)
+
+	p.mu.Lock()
+	p.ln = ln
+	p.addr = ln.Addr().String()
+	p.mu.Unlock()
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			p.mu.Lock()
+			p.accepted++
+			p.mu.Unlock()
+			go p.forward(conn)
+		}
+	}()
+}
+
+func (p *tcpProxy) forward(conn net.Conn) {
+	upstream, err := net.Dial("tcp", p.target)
+	if err != nil {
+		conn.Close()
+		return
+	}
+
+	p.mu.Lock()
+	p.conns = append(p.conns, conn, upstream)
+	p.active++
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.pipe(upstream, conn)
+		close(done)
+	}()
+	p.pipe(conn, upstream)
+	<-done
+
+	p.mu.Lock()
+	p.active--
+	p.mu.Unlock()
+}
+
+func (p *tcpProxy) pipe(dst, src net.Conn) {
+	defer dst.Close()
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := src.Read(buf)
+		if n > 0 {
+			p.gate.RLock()
+			_, werr := dst.Write(buf[:n])
+			p.gate.RUnlock()
+			if werr != nil {
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Stops forwarding data without closing connections, e.g. to simulate slow server.
+func (p *tcpProxy) pause() {
+	p.gate.Lock()
+}
+
+func (p *tcpProxy) resume() {
+	p.gate.Unlock()
+}
+
+// Returns number of proxied connections that aren't closed yet.
+func (p *tcpProxy) activeConns() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.active
+}
+
+// Returns number of accepted connections.
+func (p *tcpProxy) acceptedConns() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.accepted
+}
+
+// Closes all proxied connections. Proxy still accepts new connections.
+func (p *tcpProxy) dropConns() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, c := range p.conns {
+		c.Close()
+	}
+	p.conns = nil
+}
+
+// Stops accepting new connections and closes proxied ones.
+func (p *tcpProxy) stop() {
+	p.mu.Lock()
+	if p.ln != nil {
+		p.ln.Close()
+		p.ln = nil
+	}
+	p.mu.Unlock()
+	p.dropConns()
+}
+
+func Test_Client_AutoReconnect(t *testing.T) {
+	reconnectCfg := &adc.ReconnectConfig{
+		MaxAttempts: 2,
+		MinBackoff:  10 * time.Millisecond,
+		MaxBackoff:  50 * time.Millisecond,
+	}
+
+	t.Run("Ok", func(t *testing.T) {
+		proxy := newTCPProxy(t, "127.0.0.1:636")
+
+		cfg := getClientConfig()
+		cfg.URL = proxy.url()
+		cfg.Reconnect = reconnectCfg
+		cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		for i := 0; i < 3; i++ {
+			proxy.dropConns()
+			user, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+			require.NoError(t, err)
+			require.NotNil(t, user)
+		}
+	})
+
+	t.Run("OkIterator", func(t *testing.T) {
+		proxy := newTCPProxy(t, "127.0.0.1:636")
+
+		cfg := getClientConfig()
+		cfg.URL = proxy.url()
+		cfg.PageSize = 1
+		cfg.Reconnect = reconnectCfg
+		cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		proxy.dropConns()
+		it := cl.IterUsers(context.Background(), adc.ListUsersArgs{SkipGroupsSearch: true})
+		defer it.Close()
+		require.True(t, it.Next())
+		require.NoError(t, it.Err())
+	})
+
+	t.Run("ServerDown", func(t *testing.T) {
+		proxy := newTCPProxy(t, "127.0.0.1:636")
+
+		cfg := getClientConfig()
+		cfg.URL = proxy.url()
+		cfg.Reconnect = reconnectCfg
+		cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		proxy.stop()
+		_, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+		require.ErrorContains(t, err, "failed after '2' attempts")
+
+		proxy.start()
+		_, err = cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+		require.NoError(t, err, "Connection should be restored when server is back.")
+	})
+
+	t.Run("ContextDeadlineNotReconnected", func(t *testing.T) {
+		proxy := newTCPProxy(t, "127.0.0.1:636")
+
+		cfg := getClientConfig()
+		cfg.URL = proxy.url()
+		cfg.PoolSize = 1
+		cfg.Reconnect = reconnectCfg
+		cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+		require.Equal(t, 1, proxy.acceptedConns())
+
+		proxy.pause()
+		ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+		defer cancel()
+		_, err := cl.GetUserContext(ctx, adc.GetUserArgs{Id: "testuser1"})
+		proxy.resume()
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.NotContains(t, err.Error(), "reconnect failed")
+
+		_, err = cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+		require.NoError(t, err)
+		require.Equal(t, 1, proxy.acceptedConns(), "Connection should be reused after context deadline")
+	})
+
+	t.Run("BadBindNotRetried", func(t *testing.T) {
+		proxy := newTCPProxy(t, "127.0.0.1:636")
+
+		cfg := getClientConfig()
+		cfg.URL = proxy.url()
+		cfg.PoolIdleTimeout = time.Millisecond
+		cfg.Reconnect = &adc.ReconnectConfig{MaxAttempts: 3, MinBackoff: time.Minute}
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		// Expired idle connection is replaced with new one, which fails to bind.
+		cl.Config.Bind.Password = "bad_password"
+		time.Sleep(10 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_, err := cl.GetUserContext(ctx, adc.GetUserArgs{Id: "testuser1"})
+		require.ErrorContains(t, err, "Failed to bind")
+	})
+}
+
+func Test_Client_Reconnect_NilBind(t *testing.T) {
+	cfg := getClientConfig()
+	cfg.Bind = nil
+	cl := adc.New(&cfg)
+	require.NoError(t, cl.Connect())
+	defer cl.Disconnect()
+
+	require.NoError(t, cl.Reconnect(context.Background(), 10*time.Millisecond, 1))
+}
@@ -0,0 +1,94 @@
+package adctests
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/dlampsi/adc"
+	"github.com/stretchr/testify/require"
+)
+
+// Returns PEM-encoded self-signed certificate and its key.
+func generateCertificate(t *testing.T) (string, string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "adc-tests"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer})
+	return string(certPEM), string(keyPEM)
+}
+
+func Test_Client_TLSConfig(t *testing.T) {
+	certPEM, keyPEM := generateCertificate(t)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	require.NoError(t, os.WriteFile(caFile, []byte(certPEM), 0o600))
+
+	connect := func(t *testing.T, tlsCfg *adc.TLSConfig, insecure bool) error {
+		cfg := getClientConfig()
+		cfg.InsecureTLS = insecure
+		cfg.TLS = tlsCfg
+		cl := adc.New(&cfg)
+		err := cl.Connect()
+		if err == nil {
+			require.NoError(t, cl.Disconnect())
+		}
+		return err
+	}
+
+	t.Run("MinVersion", func(t *testing.T) {
+		require.NoError(t, connect(t, &adc.TLSConfig{MinVersion: "1.2"}, true))
+		require.ErrorContains(t, connect(t, &adc.TLSConfig{MinVersion: "2.0"}, true), "unsupported TLS version")
+	})
+	t.Run("BadCAFile", func(t *testing.T) {
+		require.Error(t, connect(t, &adc.TLSConfig{CAFile: filepath.Join(dir, "nonexists.pem")}, false))
+	})
+	t.Run("BadCAPEM", func(t *testing.T) {
+		require.ErrorContains(t, connect(t, &adc.TLSConfig{CAPEM: "bad"}, false), "no certificates found")
+	})
+	t.Run("UnknownCA", func(t *testing.T) {
+		// Server certificate isn't signed by provided CA, so verification should fail.
+		require.Error(t, connect(t, &adc.TLSConfig{CAFile: caFile}, false))
+		require.Error(t, connect(t, &adc.TLSConfig{CAPEM: certPEM}, false))
+	})
+	t.Run("ClientCertificate", func(t *testing.T) {
+		require.NoError(t, connect(t, &adc.TLSConfig{CertPEM: certPEM, KeyPEM: keyPEM}, true))
+		require.ErrorContains(t, connect(t, &adc.TLSConfig{CertPEM: certPEM}, true), "both client certificate and key are required")
+		require.ErrorContains(t, connect(t, &adc.TLSConfig{CertPEM: certPEM, KeyPEM: "bad"}, true), "invalid client certificate")
+	})
+	t.Run("CheckAuthByDN", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.InsecureTLS = false
+		cfg.TLS = &adc.TLSConfig{CAFile: caFile}
+		cl := adc.New(&cfg)
+		err := cl.CheckAuthByDN(cfg.Bind.DN, cfg.Bind.Password)
+		var authErr *adc.AuthError
+		require.Error(t, err, "TLS settings should be applied to auth check connection")
+		require.NotErrorAs(t, err, &authErr)
+	})
+}
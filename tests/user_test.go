@@ -1,6 +1,7 @@
 package adctests
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -36,6 +37,100 @@ func Test_User_GetStringAttribute(t *testing.T) {
 		}
 		require.Equal(t, "value", user.GetStringAttribute("one"))
 	})
+	t.Run("MultiValued", func(t *testing.T) {
+		user := &adc.User{
+			Attributes: map[string]interface{}{
+				"multi": []string{"first", "second"},
+			},
+		}
+		require.Equal(t, "first", user.GetStringAttribute("multi"))
+	})
+}
+
+func Test_User_GetBytesAttribute(t *testing.T) {
+	u := &adc.User{
+		Attributes: map[string]interface{}{
+			"one":   []byte("value"),
+			"multi": [][]byte{[]byte("first"), []byte("second")},
+			"str":   "string",
+		},
+	}
+	require.Equal(t, []byte("value"), u.GetBytesAttribute("one"))
+	require.Equal(t, []byte("first"), u.GetBytesAttribute("multi"))
+	require.Nil(t, u.GetBytesAttribute("str"))
+	require.Nil(t, u.GetBytesAttribute("nonexists"))
+}
+
+func Test_User_GetStringsAttribute(t *testing.T) {
+	user := &adc.User{
+		Attributes: map[string]interface{}{
+			"one":   "value",
+			"multi": []string{"first", "second"},
+			"two":   2,
+		},
+	}
+	require.Equal(t, []string{"value"}, user.GetStringsAttribute("one"))
+	require.Equal(t, []string{"first", "second"}, user.GetStringsAttribute("multi"))
+	require.Nil(t, user.GetStringsAttribute("two"))
+	require.Nil(t, user.GetStringsAttribute("nonexists"))
+}
+
+func Test_User_GetIntAttribute(t *testing.T) {
+	u := &adc.User{
+		Attributes: map[string]interface{}{
+			"userAccountControl": "512",
+			"lockoutDuration":    "-18000000000",
+			"str":                "string",
+		},
+	}
+
+	v, ok := u.GetIntAttribute("userAccountControl")
+	require.True(t, ok)
+	require.Equal(t, int64(512), v)
+
+	v, ok = u.GetIntAttribute("lockoutDuration")
+	require.True(t, ok)
+	require.Equal(t, int64(-18000000000), v)
+
+	_, ok = u.GetIntAttribute("str")
+	require.False(t, ok)
+
+	_, ok = u.GetIntAttribute("nonexists")
+	require.False(t, ok)
+}
+
+func Test_User_GetTimeAttribute(t *testing.T) {
+	u := &adc.User{
+		Attributes: map[string]interface{}{
+			"pwdLastSet":     "133485408000000000",
+			"accountExpires": "9223372036854775807",
+			"whenCreated":    "20240101120000.0Z",
+			"whenChanged":    "20240101120000Z",
+			"str":            "string",
+		},
+	}
+
+	v, ok := u.GetTimeAttribute("pwdLastSet")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), v)
+
+	v, ok = u.GetTimeAttribute("accountExpires")
+	require.True(t, ok)
+	require.True(t, v.IsZero(), "Never expires value should be converted to zero time")
+
+	v, ok = u.GetTimeAttribute("whenCreated")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), v)
+
+	v, ok = u.GetTimeAttribute("whenChanged")
+	require.True(t, ok)
+	require.Equal(t, time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC), v)
+
+	_, ok = u.GetTimeAttribute("str")
+	require.False(t, ok)
+
+	_, ok = u.GetTimeAttribute("nonexists")
+	require.False(t, ok)
 }
 
 func Test_GetUserArgs_Validate(t *testing.T) {
@@ -80,6 +175,16 @@ func Test_User_IsGroupMember(t *testing.T) {
 	})
 }
 
+func Test_User_IsDirectGroupMember(t *testing.T) {
+	u := &adc.User{
+		Groups: []adc.UserGroup{{Id: "group1"}, {Id: "group2", Inherited: true}},
+	}
+	require.True(t, u.IsDirectGroupMember("group1"))
+	require.False(t, u.IsDirectGroupMember("group2"))
+	require.True(t, u.IsGroupMember("group2"))
+	require.False(t, u.IsDirectGroupMember("group3"))
+}
+
 func Test_User_GroupsDn(t *testing.T) {
 	t.Run("EmptyGroups", func(t *testing.T) {
 		u := &adc.User{
@@ -128,7 +233,7 @@ func Test_Client_GetUser(t *testing.T) {
 			Id: "nonexists",
 		}
 		user, err := cl.GetUser(getUserReq)
-		require.NoError(t, err, "Expected no error on non exists user")
+		require.ErrorIs(t, err, adc.ErrNotFound)
 		require.Nil(t, user, "Non exists user error should return nil")
 	})
 	t.Run("TooManyEntries", func(t *testing.T) {
@@ -136,7 +241,7 @@ func Test_Client_GetUser(t *testing.T) {
 			Filter: "(&(objectClass=user))",
 		}
 		user, err := cl.GetUser(getUserReq)
-		require.Error(t, err, "Expected error on too many entries")
+		require.ErrorIs(t, err, adc.ErrTooManyEntries)
 		require.Nil(t, user, "User should be nil on error")
 	})
 	t.Run("OkById", func(t *testing.T) {
@@ -161,6 +266,20 @@ func Test_Client_GetUser(t *testing.T) {
 		require.Empty(t, user.Groups)
 		fmt.Println(user)
 	})
+	t.Run("OkWithNestedGroups", func(t *testing.T) {
+		direct, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1"})
+		require.NoError(t, err)
+		require.NotNil(t, direct)
+		require.False(t, direct.IsGroupMember("testgroup3"), "Nested group shouldn't be returned without resolving")
+
+		user, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1", ResolveNestedGroups: true})
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		require.True(t, user.IsGroupMember("testgroup1"))
+		require.True(t, user.IsDirectGroupMember("testgroup1"))
+		require.True(t, user.IsGroupMember("testgroup3"))
+		require.False(t, user.IsDirectGroupMember("testgroup3"))
+	})
 	t.Run("OkByDN", func(t *testing.T) {
 		getUserReq := adc.GetUserArgs{
 			Dn: "CN=testuser1,CN=Users,DC=adc,DC=dev",
@@ -179,6 +298,89 @@ func Test_Client_GetUser(t *testing.T) {
 		require.NotNil(t, user)
 		require.Equal(t, "testuser1", user.Id)
 	})
+	t.Run("WithContextCancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		user, err := cl.GetUserContext(ctx, adc.GetUserArgs{Id: "testuser1"})
+		require.ErrorIs(t, err, context.Canceled)
+		require.Nil(t, user)
+	})
+	t.Run("WithContextTimeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		user, err := cl.GetUserContext(ctx, adc.GetUserArgs{Id: "testuser1"})
+		require.NoError(t, err)
+		require.NotNil(t, user)
+	})
+	t.Run("OkWithMultiValuedAttributes", func(t *testing.T) {
+		req := adc.GetUserArgs{
+			Id:         "testuser1",
+			Attributes: []string{"sAMAccountName", "objectClass"},
+		}
+		user, err := cl.GetUser(req)
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		require.Contains(t, user.GetStringsAttribute("objectClass"), "user")
+		require.Contains(t, user.GetStringsAttribute("objectClass"), "person")
+		require.Equal(t, []string{"testuser1"}, user.GetStringsAttribute("sAMAccountName"))
+	})
+	t.Run("OkWithBinaryAttributes", func(t *testing.T) {
+		req := adc.GetUserArgs{
+			Id:         "testuser1",
+			Attributes: []string{"sAMAccountName", "objectGUID", "objectSid"},
+		}
+		user, err := cl.GetUser(req)
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		require.Regexp(t, "^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$", user.GetStringAttribute("objectGUID"))
+		require.Regexp(t, "^S-1-5-21-[0-9-]+$", user.GetStringAttribute("objectSid"))
+	})
+	t.Run("OkWithTypedAttributes", func(t *testing.T) {
+		req := adc.GetUserArgs{
+			Id:         "testuser1",
+			Attributes: []string{"sAMAccountName", "whenCreated", "userAccountControl", "accountExpires"},
+		}
+		user, err := cl.GetUser(req)
+		require.NoError(t, err)
+		require.NotNil(t, user)
+
+		created, ok := user.GetTimeAttribute("whenCreated")
+		require.True(t, ok)
+		require.False(t, created.IsZero())
+		require.True(t, created.Before(time.Now()))
+
+		uac, ok := user.GetIntAttribute("userAccountControl")
+		require.True(t, ok)
+		require.NotZero(t, uac)
+
+		expires, ok := user.GetTimeAttribute("accountExpires")
+		require.True(t, ok)
+		require.True(t, expires.IsZero(), "Test user account should never expire")
+	})
+	t.Run("OkWithRawBinaryAttributes", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.BinaryAttributes = []string{"objectGUID"}
+		rawCl := adc.New(&cfg)
+		require.NoError(t, rawCl.Connect())
+
+		req := adc.GetUserArgs{
+			Id:               "testuser1",
+			Attributes:       []string{"sAMAccountName", "objectGUID"},
+			SkipGroupsSearch: true,
+		}
+		user, err := rawCl.GetUser(req)
+		require.NoError(t, err)
+		require.NotNil(t, user)
+		require.Len(t, user.GetBytesAttribute("objectGUID"), 16)
+		require.Empty(t, user.GetStringAttribute("objectGUID"))
+
+		guid, err := adc.DecodeGUID(user.GetBytesAttribute("objectGUID"))
+		require.NoError(t, err)
+
+		decoded, err := cl.GetUser(adc.GetUserArgs{Id: "testuser1", Attributes: []string{"objectGUID"}})
+		require.NoError(t, err)
+		require.Equal(t, decoded.GetStringAttribute("objectGUID"), guid)
+	})
 	t.Run("OkWithAttributes", func(t *testing.T) {
 		req := adc.GetUserArgs{
 			Id:         "testuser1",
@@ -192,6 +394,125 @@ func Test_Client_GetUser(t *testing.T) {
 	})
 }
 
+func Test_Client_ListUsers(t *testing.T) {
+	cfg := getClientConfig()
+	cfg.PageSize = 1
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	t.Run("Ok", func(t *testing.T) {
+		users, err := cl.ListUsers(adc.ListUsersArgs{})
+		require.NoError(t, err)
+		require.Greater(t, len(users), 1, "Expected all users across pages")
+		require.Contains(t, usersIds(users), "testuser1")
+		require.Contains(t, usersIds(users), "testuser2")
+	})
+	t.Run("OkWithFilter", func(t *testing.T) {
+		users, err := cl.ListUsers(adc.ListUsersArgs{
+			Filter:           "(&(objectClass=user)(sAMAccountName=testuser*))",
+			SkipGroupsSearch: true,
+		})
+		require.NoError(t, err)
+		require.GreaterOrEqual(t, len(users), 2)
+		for _, u := range users {
+			require.Empty(t, u.Groups)
+		}
+	})
+	t.Run("NonExists", func(t *testing.T) {
+		users, err := cl.ListUsers(adc.ListUsersArgs{
+			Filter: "(&(objectClass=user)(sAMAccountName=nonexists))",
+		})
+		require.NoError(t, err)
+		require.Empty(t, users)
+	})
+	t.Run("BadSearchBase", func(t *testing.T) {
+		_, err := cl.ListUsers(adc.ListUsersArgs{SearchBase: "OU=nonexists,DC=adc,DC=dev"})
+		require.Error(t, err)
+	})
+}
+
+func Test_Client_IterUsers(t *testing.T) {
+	cfg := getClientConfig()
+	cfg.PageSize = 1
+	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
+	require.NoError(t, cl.Connect())
+
+	t.Run("Ok", func(t *testing.T) {
+		listed, err := cl.ListUsers(adc.ListUsersArgs{SkipGroupsSearch: true})
+		require.NoError(t, err)
+
+		it := cl.IterUsers(context.Background(), adc.ListUsersArgs{SkipGroupsSearch: true})
+		defer it.Close()
+
+		var users []*adc.User
+		for it.Next() {
+			require.NotNil(t, it.User())
+			users = append(users, it.User())
+		}
+		require.NoError(t, it.Err())
+		require.ElementsMatch(t, usersIds(listed), usersIds(users))
+	})
+	t.Run("StopEarly", func(t *testing.T) {
+		it := cl.IterUsers(context.Background(), adc.ListUsersArgs{})
+		require.True(t, it.Next())
+		require.NotEmpty(t, it.User().Id)
+		require.NoError(t, it.Close())
+		require.False(t, it.Next(), "No entries expected after close")
+		require.NoError(t, it.Err())
+	})
+	t.Run("WithContextCancel", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		it := cl.IterUsers(ctx, adc.ListUsersArgs{})
+		defer it.Close()
+
+		require.True(t, it.Next())
+		cancel()
+		for it.Next() {
+		}
+		require.ErrorIs(t, it.Err(), context.Canceled)
+	})
+	t.Run("ReleaseConnectionOnError", func(t *testing.T) {
+		cfg := getClientConfig()
+		cfg.PageSize = 1
+		cfg.PoolSize = 2
+		cl := adc.New(&cfg)
+		require.NoError(t, cl.Connect())
+		defer cl.Disconnect()
+
+		// Iterators connections are held until iterators stop on error, and pool of 2 allows a single iterator.
+		for i := 0; i < 2; i++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			it := cl.IterUsers(ctx, adc.ListUsersArgs{SkipGroupsSearch: true})
+			require.True(t, it.Next())
+			cancel()
+			for it.Next() {
+			}
+			require.ErrorIs(t, it.Err(), context.Canceled)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		it := cl.IterUsers(ctx, adc.ListUsersArgs{SkipGroupsSearch: true})
+		defer it.Close()
+		require.True(t, it.Next())
+		require.NoError(t, it.Err())
+	})
+	t.Run("BadSearchBase", func(t *testing.T) {
+		it := cl.IterUsers(context.Background(), adc.ListUsersArgs{SearchBase: "OU=nonexists,DC=adc,DC=dev"})
+		defer it.Close()
+		require.False(t, it.Next())
+		require.Error(t, it.Err())
+	})
+}
+
+func usersIds(users []*adc.User) []string {
+	var result []string
+	for _, u := range users {
+		result = append(result, u.Id)
+	}
+	return result
+}
+
 func Test_Client_CreateUser(t *testing.T) {
 	cfg := getClientConfig()
 	cl := adc.New(&cfg, adc.WithLogger(&logger{t: t}))
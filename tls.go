@@ -0,0 +1,124 @@
+package adc
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// TLS settings for LDAPS and StartTLS connections.
+// PEM data and file paths can be combined, e.g. CA from file and client certificate from PEM data.
+type TLSConfig struct {
+	// Path to PEM file with CA certificates to verify server certificate. System pool is used if no CA provided.
+	CAFile string `json:"ca_file"`
+	// PEM-encoded CA certificates to verify server certificate.
+	CAPEM string `json:"ca_pem"`
+	// Paths to PEM files with client certificate and key for mutual TLS.
+	CertFile string `json:"cert_file"`
+	KeyFile  string `json:"key_file"`
+	// PEM-encoded client certificate and key for mutual TLS.
+	CertPEM string `json:"cert_pem"`
+	KeyPEM  string `json:"key_pem"`
+	// Server name to verify server certificate against. Host from URL is used if not provided.
+	ServerName string `json:"server_name"`
+	// Minimum TLS version: '1.0', '1.1', '1.2' or '1.3'. Defaults to Go crypto/tls default.
+	MinVersion string `json:"min_version"`
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// Returns TLS config for LDAPS and StartTLS connections to provided server URL.
+func (cl *Client) tlsConfig(serverURL string) (*tls.Config, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URL: %w", err)
+	}
+	result := &tls.Config{
+		InsecureSkipVerify: cl.Config.InsecureTLS,
+		// Required for StartTLS, LDAPS dial sets it from URL anyway.
+		ServerName: u.Hostname(),
+	}
+
+	cfg := cl.Config.TLS
+	if cfg == nil {
+		return result, nil
+	}
+
+	if cfg.ServerName != "" {
+		result.ServerName = cfg.ServerName
+	}
+
+	if cfg.MinVersion != "" {
+		v, ok := tlsVersions[cfg.MinVersion]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version '%s'", cfg.MinVersion)
+		}
+		result.MinVersion = v
+	}
+
+	if cfg.CAFile != "" || cfg.CAPEM != "" {
+		pool := x509.NewCertPool()
+		if cfg.CAFile != "" {
+			data, err := os.ReadFile(cfg.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("can't read CA file: %w", err)
+			}
+			if !pool.AppendCertsFromPEM(data) {
+				return nil, fmt.Errorf("no certificates found in CA file '%s'", cfg.CAFile)
+			}
+		}
+		if cfg.CAPEM != "" && !pool.AppendCertsFromPEM([]byte(cfg.CAPEM)) {
+			return nil, errors.New("no certificates found in CA PEM")
+		}
+		result.RootCAs = pool
+	}
+
+	cert, err := cfg.clientCertificate()
+	if err != nil {
+		return nil, err
+	}
+	if cert != nil {
+		result.Certificates = []tls.Certificate{*cert}
+	}
+
+	return result, nil
+}
+
+// Returns client certificate from PEM files or data. Returns nil if client certificate isn't configured.
+func (cfg *TLSConfig) clientCertificate() (*tls.Certificate, error) {
+	certPEM, keyPEM := []byte(cfg.CertPEM), []byte(cfg.KeyPEM)
+	if cfg.CertFile != "" {
+		data, err := os.ReadFile(cfg.CertFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read client certificate file: %w", err)
+		}
+		certPEM = data
+	}
+	if cfg.KeyFile != "" {
+		data, err := os.ReadFile(cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("can't read client key file: %w", err)
+		}
+		keyPEM = data
+	}
+
+	if len(certPEM) == 0 && len(keyPEM) == 0 {
+		return nil, nil
+	}
+	if len(certPEM) == 0 || len(keyPEM) == 0 {
+		return nil, errors.New("both client certificate and key are required")
+	}
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client certificate: %w", err)
+	}
+	return &cert, nil
+}
@@ -1,37 +1,64 @@
 package adc
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/go-ldap/ldap/v3"
 )
 
 // Active Direcotry user.
+// Single-valued attributes are stored as string and multi-valued attributes as []string.
 type User struct {
 	DN         string                 `json:"dn"`
 	Id         string                 `json:"id"`
 	Attributes map[string]interface{} `json:"attributes"`
 	Groups     []UserGroup            `json:"groups"`
+	// Account flags computed from 'userAccountControl' and 'msDS-User-Account-Control-Computed' attributes.
+	AccountControl UserAccountControl `json:"account_control"`
 }
 
 // Active Direcotry user group info.
 type UserGroup struct {
 	DN string `json:"dn"`
 	Id string `json:"id"`
+	// Membership is inherited through nested groups and user isn't a direct member of the group.
+	Inherited bool `json:"inherited"`
 }
 
-// Returns string attribute by attribute name.
+// Returns string attribute by attribute name. Returns first value for multi-valued attribute.
 // Returns empty string if attribute not exists or it can't be covnerted to string.
 func (u *User) GetStringAttribute(name string) string {
-	for att, val := range u.Attributes {
-		if att == name {
-			if s, ok := val.(string); ok {
-				return s
-			}
-		}
-	}
-	return ""
+	return getStringAttribute(u.Attributes, name)
+}
+
+// Returns all values of string attribute by attribute name.
+// Returns nil if attribute not exists or it can't be covnerted to strings.
+func (u *User) GetStringsAttribute(name string) []string {
+	return getStringsAttribute(u.Attributes, name)
+}
+
+// Returns raw bytes of attribute listed in client config binary attributes.
+// Returns first value for multi-valued attribute and nil if attribute not exists or it isn't binary.
+func (u *User) GetBytesAttribute(name string) []byte {
+	return getBytesAttribute(u.Attributes, name)
+}
+
+// Returns integer attribute by attribute name, e.g. 'userAccountControl'.
+// Returns false if attribute not exists or it can't be converted to integer.
+func (u *User) GetIntAttribute(name string) (int64, bool) {
+	return getIntAttribute(u.Attributes, name)
+}
+
+// Returns time attribute by attribute name. Both Windows FILETIME ('pwdLastSet', 'accountExpires', etc.)
+// and GeneralizedTime ('whenCreated', 'whenChanged', etc.) formats are supported.
+// Returns zero time for AD 'never' values and false if attribute not exists or it can't be converted to time.
+func (u *User) GetTimeAttribute(name string) (time.Time, bool) {
+	return getTimeAttribute(u.Attributes, name)
 }
 
 type GetUserArgs struct {
@@ -45,6 +72,8 @@ type GetUserArgs struct {
 	Attributes []string `json:"attributes"`
 	// Skip search of user groups data. Can improve request time.
 	SkipGroupsSearch bool `json:"skip_groups_search"`
+	// Resolve groups inherited through nested groups membership in addition to direct groups.
+	ResolveNestedGroups bool `json:"resolve_nested_groups"`
 }
 
 func (args GetUserArgs) Validate() error {
@@ -54,7 +83,13 @@ func (args GetUserArgs) Validate() error {
 	return nil
 }
 
+// Same as GetUserContext with background context.
 func (cl *Client) GetUser(args GetUserArgs) (*User, error) {
+	return cl.GetUserContext(context.Background(), args)
+}
+
+// Returns user found by provided args. Returns 'ErrNotFound' error if user not found.
+func (cl *Client) GetUserContext(ctx context.Context, args GetUserArgs) (*User, error) {
 	if err := args.Validate(); err != nil {
 		return nil, err
 	}
@@ -75,33 +110,58 @@ func (cl *Client) GetUser(args GetUserArgs) (*User, error) {
 		DerefAliases: ldap.NeverDerefAliases,
 		TimeLimit:    int(cl.Config.Timeout.Seconds()),
 		Filter:       filter,
-		Attributes:   cl.Config.Users.Attributes,
-	}
-	if args.Attributes != nil {
-		req.Attributes = args.Attributes
+		Attributes:   cl.userSearchAttributes(args.Attributes),
 	}
 
-	entry, err := cl.searchEntry(req)
+	entry, err := cl.searchEntry(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 	if entry == nil {
-		return nil, nil
+		return nil, fmt.Errorf("user %w", ErrNotFound)
+	}
+
+	return cl.userFromEntry(ctx, entry, args)
+}
+
+// Returns attributes to request for users search. Account control attributes are always requested.
+func (cl *Client) userSearchAttributes(attributes []string) []string {
+	if attributes == nil {
+		attributes = cl.Config.Users.Attributes
+	}
+	result := slices.Clone(attributes)
+	for _, a := range accountControlAttributes {
+		if !containsFold(result, a) {
+			result = append(result, a)
+		}
 	}
+	return result
+}
 
+// Maps ldap entry to user and fetches user groups if needed.
+func (cl *Client) userFromEntry(ctx context.Context, entry *ldap.Entry, args GetUserArgs) (*User, error) {
 	result := &User{
-		DN:         entry.DN,
-		Id:         entry.GetAttributeValue(cl.Config.Users.IdAttribute),
-		Attributes: make(map[string]interface{}, len(entry.Attributes)),
+		DN:             entry.DN,
+		Id:             entryAttributeValue(entry, cl.Config.Users.IdAttribute),
+		Attributes:     cl.entryAttributes(entry),
+		AccountControl: entryAccountControl(entry),
 	}
-	for _, a := range entry.Attributes {
-		result.Attributes[a.Name] = entry.GetAttributeValue(a.Name)
+
+	// Account control attributes are requested implicitly, so keep it only if they were requested.
+	requested := args.Attributes
+	if requested == nil {
+		requested = cl.Config.Users.Attributes
+	}
+	for _, a := range accountControlAttributes {
+		if !containsFold(requested, a) {
+			delete(result.Attributes, a)
+		}
 	}
 
 	if !args.SkipGroupsSearch {
-		groups, err := cl.getUserGroups(entry.DN)
+		groups, err := cl.getUserGroups(ctx, entry.DN, args.ResolveNestedGroups)
 		if err != nil {
-			return nil, fmt.Errorf("can't get user groups: %s", err.Error())
+			return nil, fmt.Errorf("can't get user groups: %w", err)
 		}
 		result.Groups = groups
 	}
@@ -109,16 +169,100 @@ func (cl *Client) GetUser(args GetUserArgs) (*User, error) {
 	return result, nil
 }
 
-func (cl *Client) getUserGroups(dn string) ([]UserGroup, error) {
+type ListUsersArgs struct {
+	// Optional LDAP filter to search entries. Client config users filter used if not provided.
+	Filter string `json:"filter"`
+	// Optional base OU to search users. Overwrites users search base in client config.
+	SearchBase string `json:"search_base"`
+	// Optional user attributes to overwrite attributes in client config.
+	Attributes []string `json:"attributes"`
+	// Skip search of users groups data. Groups are requested separately for each user,
+	// so it's recommended for large result sets.
+	SkipGroupsSearch bool `json:"skip_groups_search"`
+	// Resolve groups inherited through nested groups membership in addition to direct groups.
+	ResolveNestedGroups bool `json:"resolve_nested_groups"`
+}
+
+func (args ListUsersArgs) getUserArgs() GetUserArgs {
+	return GetUserArgs{
+		Attributes:          args.Attributes,
+		SkipGroupsSearch:    args.SkipGroupsSearch,
+		ResolveNestedGroups: args.ResolveNestedGroups,
+	}
+}
+
+// Same as ListUsersContext with background context.
+func (cl *Client) ListUsers(args ListUsersArgs) ([]*User, error) {
+	return cl.ListUsersContext(context.Background(), args)
+}
+
+// Returns all users found by provided args. Uses paged search, so result isn't limited by server page size.
+func (cl *Client) ListUsersContext(ctx context.Context, args ListUsersArgs) ([]*User, error) {
+	entries, err := cl.searchEntries(ctx, cl.listUsersRequest(args))
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]*User, 0, len(entries))
+	for _, entry := range entries {
+		user, err := cl.userFromEntry(ctx, entry, args.getUserArgs())
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, user)
+	}
+	return result, nil
+}
+
+func (cl *Client) listUsersRequest(args ListUsersArgs) *ldap.SearchRequest {
+	req := &ldap.SearchRequest{
+		BaseDN:       cl.Config.Users.SearchBase,
+		Scope:        ldap.ScopeWholeSubtree,
+		DerefAliases: ldap.NeverDerefAliases,
+		TimeLimit:    int(cl.Config.Timeout.Seconds()),
+		Filter:       cl.Config.Users.FilterAll,
+		Attributes:   cl.userSearchAttributes(args.Attributes),
+	}
+	if args.Filter != "" {
+		req.Filter = args.Filter
+	}
+	if args.SearchBase != "" {
+		req.BaseDN = args.SearchBase
+	}
+	return req
+}
+
+func (cl *Client) getUserGroups(ctx context.Context, dn string, nested bool) ([]UserGroup, error) {
+	direct, err := cl.searchUserGroups(ctx, cl.Config.Users.FilterGroupsByDn, dn)
+	if err != nil {
+		return nil, err
+	}
+	if !nested {
+		return direct, nil
+	}
+
+	all, err := cl.searchUserGroups(ctx, cl.Config.Users.FilterNestedGroupsByDn, dn)
+	if err != nil {
+		return nil, err
+	}
+	for i := range all {
+		all[i].Inherited = !slices.ContainsFunc(direct, func(g UserGroup) bool {
+			return strings.EqualFold(g.DN, all[i].DN)
+		})
+	}
+	return all, nil
+}
+
+func (cl *Client) searchUserGroups(ctx context.Context, filter string, dn string) ([]UserGroup, error) {
 	req := &ldap.SearchRequest{
 		BaseDN:       cl.Config.Groups.SearchBase,
 		Scope:        ldap.ScopeWholeSubtree,
 		DerefAliases: ldap.NeverDerefAliases,
 		TimeLimit:    int(cl.Config.Timeout.Seconds()),
-		Filter:       fmt.Sprintf(cl.Config.Users.FilterGroupsByDn, ldap.EscapeFilter(dn)),
+		Filter:       fmt.Sprintf(filter, ldap.EscapeFilter(dn)),
 		Attributes:   []string{cl.Config.Groups.IdAttribute},
 	}
-	entries, err := cl.searchEntries(req)
+	entries, err := cl.searchEntries(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -126,12 +270,14 @@ func (cl *Client) getUserGroups(dn string) ([]UserGroup, error) {
 	for _, e := range entries {
 		result = append(result, UserGroup{
 			DN: e.DN,
-			Id: e.GetAttributeValue(cl.Config.Groups.IdAttribute),
+			Id: entryAttributeValue(e, cl.Config.Groups.IdAttribute),
 		})
 	}
 	return result, nil
 }
 
+// Returns true if user is a member of the group by group ID.
+// Inherited membership is considered only if user was requested with nested groups resolving.
 func (u *User) IsGroupMember(groupId string) bool {
 	for _, g := range u.Groups {
 		if g.Id == groupId {
@@ -141,6 +287,16 @@ func (u *User) IsGroupMember(groupId string) bool {
 	return false
 }
 
+// Returns true if user is a direct member of the group by group ID.
+func (u *User) IsDirectGroupMember(groupId string) bool {
+	for _, g := range u.Groups {
+		if g.Id == groupId && !g.Inherited {
+			return true
+		}
+	}
+	return false
+}
+
 // Returns list of user groups DNs.
 func (u *User) GroupsDn() []string {
 	var result []string
@@ -159,6 +315,22 @@ func (u *User) GroupsId() []string {
 	return result
 }
 
+// Returns user by ID with provided attributes only. Returns error if user not found.
+func (cl *Client) findUser(ctx context.Context, userId string, attributes ...string) (*User, error) {
+	if len(attributes) == 0 {
+		attributes = []string{cl.Config.Users.IdAttribute}
+	}
+	user, err := cl.GetUserContext(ctx, GetUserArgs{
+		Id:               userId,
+		Attributes:       attributes,
+		SkipGroupsSearch: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("can't get user '%s': %w", userId, err)
+	}
+	return user, nil
+}
+
 type CreateUserArgs struct {
 	Id         string
 	Password   string
@@ -175,8 +347,13 @@ func (args CreateUserArgs) Validate() error {
 	return nil
 }
 
-// Creates a new user.
+// Same as CreateUserContext with background context.
 func (cl *Client) CreateUser(args CreateUserArgs) error {
+	return cl.CreateUserContext(context.Background(), args)
+}
+
+// Creates a new user.
+func (cl *Client) CreateUserContext(ctx context.Context, args CreateUserArgs) error {
 	if err := args.Validate(); err != nil {
 		return fmt.Errorf("Bad request: %w", err)
 	}
@@ -197,8 +374,16 @@ func (cl *Client) CreateUser(args CreateUserArgs) error {
 	if _, ok := args.Attributes["cn"]; !ok {
 		args.Attributes["cn"] = []string{args.Id}
 	}
-	if _, ok := args.Attributes["userPassword"]; !ok {
-		args.Attributes["userPassword"] = []string{args.Password}
+
+	// AD ignores 'userPassword' unless it's explicitly enabled, so password is set via 'unicodePwd',
+	// which requires secure connection. Provide 'userPassword' attribute explicitly to use it instead.
+	_, hasUserPassword := args.Attributes["userPassword"]
+	_, hasUnicodePwd := args.Attributes["unicodePwd"]
+	if !hasUserPassword && !hasUnicodePwd {
+		if err := cl.checkSecure(ctx); err != nil {
+			return err
+		}
+		args.Attributes["unicodePwd"] = []string{encodePassword(args.Password)}
 	}
 
 	for k, v := range args.Attributes {
@@ -207,18 +392,23 @@ func (cl *Client) CreateUser(args CreateUserArgs) error {
 
 	entryDn := fmt.Sprintf("CN=%s,%s", args.Id, cl.Config.Users.SearchBase)
 
-	return cl.createEntry(entryDn, attributes)
+	return passwordError(cl.createEntry(ctx, entryDn, attributes))
 }
 
-// Deletes an user by ID.
+// Same as DeleteUserContext with background context.
 func (cl *Client) DeleteUser(userId string) error {
-	entry, err := cl.GetUser(GetUserArgs{Id: userId})
-	if err != nil {
-		return fmt.Errorf("Failed to get group: %w", err)
-	}
-	if entry == nil {
+	return cl.DeleteUserContext(context.Background(), userId)
+}
+
+// Deletes an user by ID.
+func (cl *Client) DeleteUserContext(ctx context.Context, userId string) error {
+	entry, err := cl.GetUserContext(ctx, GetUserArgs{Id: userId, SkipGroupsSearch: true})
+	if errors.Is(err, ErrNotFound) {
 		cl.logger.Debugf("User '%s' already doesn't exist", userId)
 		return nil
 	}
-	return cl.deleteEntry(entry.DN)
+	if err != nil {
+		return fmt.Errorf("Failed to get user: %w", err)
+	}
+	return cl.deleteEntry(ctx, entry.DN)
 }